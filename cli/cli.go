@@ -2,11 +2,20 @@ package cli
 
 import (
 	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/vsoch/uptodate/config"
+	"github.com/vsoch/uptodate/parsers/docker"
+	"github.com/vsoch/uptodate/parsers/goproxy"
+	"github.com/vsoch/uptodate/parsers/npm"
+	"github.com/vsoch/uptodate/parsers/pypi"
+	"github.com/vsoch/uptodate/utils"
 	"github.com/vsoch/uptodate/version"
 )
 
 // GlobalFlags contains the flags for commands.
-type GlobalFlags struct{}
+type GlobalFlags struct {
+	NoCache bool `long:"no-cache" desc:"Don't read or write the cache of remote lookups."`
+	Refresh bool `long:"refresh" desc:"Check every cached remote lookup again, regardless of age."`
+}
 
 // Root is the main command.
 var Root *cmd.Root
@@ -19,8 +28,32 @@ func init() {
 		Version:   version.Version,
 		Copyright: "© 2021 Vanessa Sochat <@vsoch>",
 		License:   "Licensed under the Apache License, Version 2.0",
+		Flags:     &GlobalFlags{},
 	}
 	cmd.Register(&cmd.Help)
 	cmd.Register(&cmd.Version)
 	cmd.Register(&cmd.GenManPages)
 }
+
+// applyGlobalFlags sets flags shared by all commands
+func applyGlobalFlags(r *cmd.Root) {
+	flags := r.Flags.(*GlobalFlags)
+	utils.CacheDisabled = flags.NoCache
+	utils.CacheRefresh = flags.Refresh
+}
+
+// applyRepoConfig loads the repository config (.uptodate.yaml) found for a path
+func applyRepoConfig(path string) config.RepoConfig {
+	conf := config.LoadRepoConfig(path)
+	docker.DefaultRegistry.Mirrors = conf.Mirrors
+	if conf.Sources.PyPI.URL != "" {
+		pypi.IndexURL = conf.Sources.PyPI.URL
+	}
+	if conf.Sources.Npm.URL != "" {
+		npm.RegistryURL = conf.Sources.Npm.URL
+	}
+	if conf.Sources.Go.URL != "" {
+		goproxy.ProxyURL = conf.Sources.Go.URL
+	}
+	return conf
+}
@@ -39,6 +39,8 @@ func init() {
 // RunDockerfile updates one or more Dockerfile
 func RunDockerBases(r *cmd.Root, c *cmd.Sub) {
 
+	applyGlobalFlags(r)
+
 	args := c.Args.(*DockerBasesArgs)
 	flags := c.Flags.(*DockerBasesFlags)
 
@@ -47,6 +49,9 @@ func RunDockerBases(r *cmd.Root, c *cmd.Sub) {
 		args.Root = []string{utils.GetPwd()}
 	}
 
+	// Repository settings (e.g., registry mirrors)
+	applyRepoConfig(args.Root[0])
+
 	if flags.Bases == "" {
 		fmt.Println("Please provide a --bases root.")
 		os.Exit(1)
@@ -36,6 +36,8 @@ func init() {
 // RunDockerfile updates one or more Dockerfile
 func RunDockerBuild(r *cmd.Root, c *cmd.Sub) {
 
+	applyGlobalFlags(r)
+
 	args := c.Args.(*DockerBuildArgs)
 	flags := c.Flags.(*DockerBuildFlags)
 
@@ -44,6 +46,9 @@ func RunDockerBuild(r *cmd.Root, c *cmd.Sub) {
 		args.Root = []string{utils.GetPwd()}
 	}
 
+	// Repository settings (e.g., registry mirrors)
+	applyRepoConfig(args.Root[0])
+
 	// Print the logo!
 	fmt.Println(utils.GetLogo() + "                     dockerbuild\n")
 
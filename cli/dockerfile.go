@@ -2,6 +2,8 @@ package cli
 
 import (
 	"fmt"
+	"os"
+
 	"github.com/DataDrake/cli-ng/v2/cmd"
 	"github.com/vsoch/uptodate/parsers/docker"
 	"github.com/vsoch/uptodate/utils"
@@ -12,9 +14,13 @@ type DockerfileArgs struct {
 	Root []string `zero:"true" desc:"A Dockerfile or directory to parse."`
 }
 type DockerfileFlags struct {
-	DryRun  bool   `long:"dry-run" desc:"Preview changes but don't write."`
-	Changes bool   `long:"changes" desc:"Only consider changed uptodate files"`
-	Branch  string `long:"branch" desc:"Branch to compare HEAD against, defaults to main"`
+	DryRun   bool   `long:"dry-run" desc:"Preview changes but don't write."`
+	Changes  bool   `long:"changes" desc:"Only consider changed uptodate files"`
+	Branch   string `long:"branch" desc:"Branch to compare HEAD against, defaults to main"`
+	Digest   string `long:"digest" desc:"Pin the index (default) or platform digest of multi-arch images"`
+	Platform string `long:"platform" desc:"Platform (os/arch[/variant]) for platform digests, defaults to linux/amd64"`
+	Check    bool   `long:"check-digests" desc:"Check that pinned digests still exist instead of updating."`
+	Key      string `long:"verify-key" desc:"Only pin digests with a cosign signature from this public key"`
 }
 
 // Dockerfile updates one or more Dockerfile
@@ -34,6 +40,8 @@ func init() {
 // RunDockerfile updates one or more Dockerfile
 func RunDockerfile(r *cmd.Root, c *cmd.Sub) {
 
+	applyGlobalFlags(r)
+
 	args := c.Args.(*DockerfileArgs)
 	flags := c.Flags.(*DockerfileFlags)
 
@@ -42,16 +50,47 @@ func RunDockerfile(r *cmd.Root, c *cmd.Sub) {
 		args.Root = []string{utils.GetPwd()}
 	}
 
+	// Repository settings (e.g., registry mirrors)
+	repoConf := applyRepoConfig(args.Root[0])
+
 	// Set default branch
 	if flags.Branch == "" {
 		flags.Branch = "main"
 	}
 
+	// The digest policy for multi-arch images
+	policy, err := docker.NewDigestPolicy(flags.Digest, flags.Platform)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Require signatures from a key, from the command line or repository config
+	var verifier *docker.SignatureVerifier
+	if flags.Key == "" {
+		flags.Key = repoConf.SignatureKey()
+	}
+	if flags.Key != "" {
+		verifier, err = docker.NewSignatureVerifier(flags.Key)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
 	// Print the logo!
 	fmt.Println(utils.GetLogo() + "                     dockerfile\n")
 
 	// Update the dockerfiles with a Dockerfile parser
-	parser := docker.DockerfileParser{}
+	parser := docker.DockerfileParser{Policy: policy, Verifier: verifier, Images: repoConf.Images}
+
+	// Or just check existing pins, failing if any are gone
+	if flags.Check {
+		if missing := parser.CheckDigests(args.Root[0], flags.Changes, flags.Branch); missing > 0 {
+			os.Exit(1)
+		}
+		return
+	}
 	parser.Parse(args.Root[0], flags.DryRun, flags.Changes, flags.Branch)
 
 }
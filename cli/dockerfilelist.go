@@ -16,6 +16,7 @@ type DockerfileListFlags struct {
 	NoIncludeArgs bool   `long:"no-build-args" desc:"Do not include Dockerfile with any build args (defaults to false)"`
 	Changes       bool   `long:"changes" desc:"Only consider changed uptodate files"`
 	Branch        string `long:"branch" desc:"Branch to compare HEAD against, defaults to main"`
+	Stages        bool   `long:"stages" desc:"Show the build stages of each Dockerfile"`
 }
 
 // Dockerfile updates one or more Dockerfile
@@ -35,6 +36,8 @@ func init() {
 // RunDockerfile updates one or more Dockerfile
 func RunDockerfileList(r *cmd.Root, c *cmd.Sub) {
 
+	applyGlobalFlags(r)
+
 	args := c.Args.(*DockerfileListArgs)
 	flags := c.Flags.(*DockerfileListFlags)
 
@@ -49,6 +52,6 @@ func RunDockerfileList(r *cmd.Root, c *cmd.Sub) {
 	}
 
 	// Update the dockerfiles with a Dockerfile parser
-	parser := docker.DockerfileListParser{}
+	parser := docker.DockerfileListParser{ShowStages: flags.Stages}
 	parser.Parse(args.Root, !flags.NoEmptyArgs, !flags.NoIncludeArgs, flags.Changes, flags.Branch)
 }
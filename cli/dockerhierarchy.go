@@ -32,6 +32,8 @@ func init() {
 // RunDockerHierarchy updates a docker hierarchy
 func RunDockerHierarchy(r *cmd.Root, c *cmd.Sub) {
 
+	applyGlobalFlags(r)
+
 	args := c.Args.(*DockerHierarchyArgs)
 	flags := c.Flags.(*DockerHierarchyFlags)
 
@@ -40,6 +42,9 @@ func RunDockerHierarchy(r *cmd.Root, c *cmd.Sub) {
 		args.Root = []string{utils.GetPwd()}
 	}
 
+	// Repository settings (e.g., registry mirrors)
+	applyRepoConfig(args.Root[0])
+
 	// Print the logo!
 	fmt.Println(utils.GetLogo() + "               dockerhierarchy\n")
 
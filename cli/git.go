@@ -32,6 +32,8 @@ func init() {
 // RunGit to get changed files
 func RunGit(r *cmd.Root, c *cmd.Sub) {
 
+	applyGlobalFlags(r)
+
 	args := c.Args.(*GitArgs)
 	flags := c.Flags.(*GitFlags)
 
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigName is the repository level config, found at or above the path we parse
+var RepoConfigName = ".uptodate.yaml"
+
+// RepoConfig holds settings for a whole repository
+type RepoConfig struct {
+	Path       string     `yaml:"-"`
+	Mirrors    []Mirror   `yaml:"mirrors,omitempty"`
+	Signatures Signatures `yaml:"signatures,omitempty"`
+	Images     []Image    `yaml:"images,omitempty"`
+	Sources    Sources    `yaml:"sources,omitempty"`
+}
+
+// Sources points version sources at another index or registry (e.g., a mirror)
+type Sources struct {
+	PyPI Source `yaml:"pypi,omitempty"`
+	Npm  Source `yaml:"npm,omitempty"`
+	Go   Source `yaml:"go,omitempty"`
+}
+
+// Source holds settings for one version source
+type Source struct {
+	URL string `yaml:"url,omitempty"`
+}
+
+// Image holds settings for one image, matched by name (e.g., python or docker.io/library/python)
+type Image struct {
+	Name string `yaml:"name"`
+
+	// Move to the newest tag by patch, minor, major, or regex:<pattern>, keeping a suffix like -slim
+	Bump string `yaml:"bump,omitempty"`
+}
+
+// Signatures requires new digests to be signed by a key (a path relative to the config)
+type Signatures struct {
+	Key string `yaml:"key,omitempty"`
+}
+
+// Mirror maps references written with a prefix to the registry they are resolved against
+// e.g., mirror.corp/dockerhub/library/ubuntu is looked up as docker.io/library/ubuntu
+type Mirror struct {
+	Prefix   string `yaml:"prefix"`
+	Upstream string `yaml:"upstream"`
+
+	// Rewrite references to the upstream to use the prefix
+	Rewrite bool `yaml:"rewrite,omitempty"`
+}
+
+// SignatureKey returns the path to the signature key, if one is set
+func (c *RepoConfig) SignatureKey() string {
+	key := c.Signatures.Key
+	if key == "" || filepath.IsAbs(key) || c.Path == "" {
+		return key
+	}
+	return filepath.Join(filepath.Dir(c.Path), key)
+}
+
+// FindRepoConfig looks for the repo config (or UPTODATE_CONFIG) from a path upward,
+// stopping at the root of a git repository. An empty string means none was found.
+func FindRepoConfig(path string) string {
+	if configFile := os.Getenv("UPTODATE_CONFIG"); configFile != "" {
+		return configFile
+	}
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	for {
+		configFile := filepath.Join(dir, RepoConfigName)
+		if _, err := os.Stat(configFile); err == nil {
+			return configFile
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadRepoConfig loads the repo config for a path, an empty config if there is none
+func LoadRepoConfig(path string) RepoConfig {
+	conf := RepoConfig{}
+	configFile := FindRepoConfig(path)
+	if configFile == "" {
+		return conf
+	}
+	content, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		log.Fatalf("Cannot read %s: %s\n", configFile, err)
+	}
+	if err := yaml.Unmarshal(content, &conf); err != nil {
+		log.Fatalf("Cannot parse %s: %s\n", configFile, err)
+	}
+	for _, image := range conf.Images {
+		if image.Name == "" {
+			log.Fatalf("Every image in %s needs a name\n", configFile)
+		}
+	}
+	for _, mirror := range conf.Mirrors {
+		if mirror.Prefix == "" || mirror.Upstream == "" {
+			log.Fatalf("Every mirror in %s needs a prefix and an upstream\n", configFile)
+		}
+	}
+	conf.Path = configFile
+	return conf
+}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"github.com/mitchellh/mapstructure"
 	"gopkg.in/yaml.v3"
 	"io/ioutil"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Container struct {
@@ -14,6 +18,22 @@ type Container struct {
 	EndAt    string   `yaml:"endat,omitempty"`
 	Skips    []string `yaml:"skips,omitempty"`
 	Includes []string `yaml:"includes,omitempty"`
+	MaxTags  int      `yaml:"maxtags,omitempty"`
+
+	// Ages like 365d or 12h, to drop old versions or hold back new ones
+	NewerThan string `yaml:"newer_than,omitempty" mapstructure:"newer_than"`
+	MinAge    string `yaml:"min_age,omitempty" mapstructure:"min_age"`
+
+	// Order versions by an image label instead of the tag (tag or label), reading
+	// the labels of at most label_max_tags tags
+	VersionFrom  string `yaml:"version_from,omitempty" mapstructure:"version_from"`
+	VersionLabel string `yaml:"version_label,omitempty" mapstructure:"version_label"`
+	LabelMaxTags int    `yaml:"label_max_tags,omitempty" mapstructure:"label_max_tags"`
+}
+
+// GetVersionLabel returns the label to read versions from, empty to use tags
+func (c *Container) GetVersionLabel() string {
+	return getVersionLabel(c.Name, c.VersionFrom, c.VersionLabel)
 }
 
 type DockerHierarchy struct {
@@ -42,7 +62,23 @@ type BuildArg struct {
 	Filter   []string          `yaml:"filter,omitempty"`
 	Skips    []string          `yaml:"skips,omitempty"`
 	Includes []string          `yaml:"includes,omitempty"`
+	MaxTags  int               `yaml:"maxtags,omitempty"`
 	Params   map[string]string `yaml:"params,omitempty"`
+
+	// Ages like 365d or 12h, to drop old versions or hold back new ones
+	NewerThan string `yaml:"newer_than,omitempty" mapstructure:"newer_than"`
+	MinAge    string `yaml:"min_age,omitempty" mapstructure:"min_age"`
+
+	// Order versions by an image label instead of the tag (tag or label), reading
+	// the labels of at most label_max_tags tags
+	VersionFrom  string `yaml:"version_from,omitempty" mapstructure:"version_from"`
+	VersionLabel string `yaml:"version_label,omitempty" mapstructure:"version_label"`
+	LabelMaxTags int    `yaml:"label_max_tags,omitempty" mapstructure:"label_max_tags"`
+}
+
+// GetVersionLabel returns the label to read versions from, empty to use tags
+func (b *BuildArg) GetVersionLabel() string {
+	return getVersionLabel(b.Name, b.VersionFrom, b.VersionLabel)
 }
 
 // Get the identifier for a build arg
@@ -128,10 +164,78 @@ type Conf struct {
 	DockerBuild     DockerBuild     `yaml:"dockerbuild,omitempty"`
 }
 
-func Load(yamlfile string) Conf {
+// Load reads an uptodate.yaml, and returns an error if a setting is invalid
+func Load(yamlfile string) (Conf, error) {
 	yamlContent, err := ioutil.ReadFile(yamlfile)
 	if err != nil {
 		log.Printf("yamlFile.Get err   #%v ", err)
 	}
-	return readConfig(yamlContent)
+	conf := readConfig(yamlContent)
+	return conf, conf.Validate()
+}
+
+// Validate checks settings that are otherwise only used deep into parsing
+func (c *Conf) Validate() error {
+	container := c.DockerHierarchy.Container
+	if err := validateAges(container.Name, container.NewerThan, container.MinAge); err != nil {
+		return err
+	}
+	for key, buildarg := range c.DockerBuild.BuildArgs {
+		if err := validateAges(key, buildarg.NewerThan, buildarg.MinAge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAges ensures newer_than and min_age (if set) can be parsed
+func validateAges(name string, newerThan string, minAge string) error {
+	if newerThan != "" {
+		if _, err := ParseAge(newerThan); err != nil {
+			return fmt.Errorf("newer_than for %s: %s", name, err)
+		}
+	}
+	if minAge != "" {
+		if _, err := ParseAge(minAge); err != nil {
+			return fmt.Errorf("min_age for %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// ParseAge parses an age like 365d, 2w, or 12h into a duration
+func ParseAge(age string) (time.Duration, error) {
+	age = strings.TrimSpace(age)
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if strings.HasSuffix(age, suffix) {
+			count, err := strconv.ParseFloat(strings.TrimSuffix(age, suffix), 64)
+			if err != nil {
+				return 0, fmt.Errorf("%s is not a valid age", age)
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+	return time.ParseDuration(age)
+}
+
+// DefaultVersionLabel is the label we read versions from, unless another is set
+var DefaultVersionLabel = "org.opencontainers.image.version"
+
+// getVersionLabel validates where versions come from for a container,
+// and setting a version label alone implies versions come from it
+func getVersionLabel(name string, versionFrom string, versionLabel string) string {
+	switch versionFrom {
+	case "":
+		return versionLabel
+	case "tag":
+		return ""
+	case "label":
+		if versionLabel != "" {
+			return versionLabel
+		}
+		return DefaultVersionLabel
+	}
+	log.Fatalf("version_from for %s must be tag or label, found %s\n", name, versionFrom)
+	return ""
 }
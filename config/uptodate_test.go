@@ -0,0 +1,52 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"365d": 365 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"12h":  12 * time.Hour,
+		" 3d ": 3 * 24 * time.Hour,
+	}
+	for age, want := range tests {
+		got, err := ParseAge(age)
+		if err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %s, %v, want %s", age, got, err, want)
+		}
+	}
+	for _, age := range []string{"", "d", "a year", "3 days", "3y"} {
+		if got, err := ParseAge(age); err == nil {
+			t.Errorf("ParseAge(%q) = %s, want an error", age, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Conf{}
+	valid.DockerHierarchy.Container = Container{Name: "ubuntu", NewerThan: "365d", MinAge: "3d"}
+	valid.DockerBuild.BuildArgs = map[string]BuildArg{"ubuntu_version": {NewerThan: "52w"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() returned an error: %s", err)
+	}
+
+	tests := []struct {
+		conf Conf
+		want string
+	}{
+		{Conf{DockerHierarchy: DockerHierarchy{Container: Container{Name: "ubuntu", NewerThan: "a year"}}}, "newer_than for ubuntu"},
+		{Conf{DockerHierarchy: DockerHierarchy{Container: Container{Name: "ubuntu", MinAge: "3 days"}}}, "min_age for ubuntu"},
+		{Conf{DockerBuild: DockerBuild{BuildArgs: map[string]BuildArg{"spack_version": {MinAge: "soon"}}}}, "min_age for spack_version"},
+	}
+	for _, tt := range tests {
+		err := tt.conf.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate() returned %v, want an error for %q", err, tt.want)
+		}
+	}
+}
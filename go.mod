@@ -4,13 +4,15 @@ go 1.13
 
 require (
 	github.com/DataDrake/cli-ng/v2 v2.0.2
-	github.com/alecbcs/lookout v1.2.7
+	github.com/Microsoft/go-winio v0.5.0 // indirect
 	github.com/asottile/dockerfile v3.1.0+incompatible
 	github.com/blang/semver/v4 v4.0.0
 	github.com/go-git/go-git/v5 v5.4.2
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/mitchellh/mapstructure v1.3.1
 	github.com/moby/buildkit v0.9.0 // indirect
+	golang.org/x/net v0.0.0-20210520170846-37e1c6afe023 // indirect
+	golang.org/x/sys v0.0.0-20210521090106-6ca3eb03dfc2 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
 
@@ -1,10 +1,13 @@
 package parsers
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/blang/semver/v4"
+	"github.com/vsoch/uptodate/config"
 	"github.com/vsoch/uptodate/utils"
 )
 
@@ -30,11 +33,18 @@ type BuildResult struct {
 	Description   string            `json:"description,omitempty"`
 }
 
-// An update to a FROM includes the original content and update
+// An update to a FROM includes the original content and update.
+// When a Token is set, only that token is replaced (with the Replacement)
+// between LineNo and EndLineNo, and the rest of the instruction is kept as written.
+// The Type is what was updated, e.g., from, arg, copy, run, or syntax.
 type Update struct {
-	Original string
-	Updated  string
-	LineNo   int
+	Type        string
+	Original    string
+	Updated     string
+	LineNo      int
+	EndLineNo   int
+	Token       string
+	Replacement string
 }
 
 // BuildVariable holds a key (name) and one or more values to parameterize over
@@ -85,9 +95,6 @@ func HasSemanticVersions(contenders []string) bool {
 
 func GetVersions(contenders []string, filters []string, startAtVersion string, endAtVersion string, skipVersions []string, includeVersions []string) []string {
 
-	// Final list of versions we will provide
-	versions := []string{}
-
 	// Do we have semantic versions? If so, this is the better sort
 	if HasSemanticVersions(contenders) {
 		newContenders := SortVersions(contenders)
@@ -98,6 +105,15 @@ func GetVersions(contenders []string, filters []string, startAtVersion string, e
 		}
 	}
 
+	return FilterVersions(contenders, filters, startAtVersion, endAtVersion, skipVersions, includeVersions)
+}
+
+// FilterVersions selects versions (already sorted from oldest to newest) within user preferences
+func FilterVersions(contenders []string, filters []string, startAtVersion string, endAtVersion string, skipVersions []string, includeVersions []string) []string {
+
+	// Final list of versions we will provide
+	versions := []string{}
+
 	// We look for tags based on filters (this is an OR between them)
 	filter := "(" + strings.Join(filters, "|") + ")"
 	isVersionRegex, _ := regexp.Compile(filter)
@@ -139,3 +155,40 @@ func GetVersions(contenders []string, filters []string, startAtVersion string, e
 	}
 	return versions
 }
+
+// FilterByAge drops versions created longer than newerThan ago, and holds back versions
+// created less than minAge ago. Versions without a known creation time are kept.
+func FilterByAge(versions []string, created map[string]time.Time, newerThan string, minAge string) []string {
+	if newerThan == "" && minAge == "" {
+		return versions
+	}
+
+	// An empty age means no limit, and ages are validated when the config loads
+	var maxAge, minimum time.Duration
+	if newerThan != "" {
+		maxAge, _ = config.ParseAge(newerThan)
+	}
+	if minAge != "" {
+		minimum, _ = config.ParseAge(minAge)
+	}
+
+	filtered := []string{}
+	for _, version := range versions {
+		when, ok := created[version]
+		if !ok || when.IsZero() {
+			fmt.Printf("Creation time of %s is unknown, not filtering by age.\n", version)
+			filtered = append(filtered, version)
+			continue
+		}
+		age := time.Since(when)
+		if maxAge > 0 && age > maxAge {
+			continue
+		}
+		if minimum > 0 && age < minimum {
+			fmt.Printf("Holding back %s, it is newer than %s.\n", version, minAge)
+			continue
+		}
+		filtered = append(filtered, version)
+	}
+	return filtered
+}
@@ -0,0 +1,45 @@
+package parsers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterByAge(t *testing.T) {
+	now := time.Now()
+	versions := []string{"1.0", "2.0", "3.0", "4.0"}
+	created := map[string]time.Time{
+		"1.0": now.Add(-400 * 24 * time.Hour),
+		"2.0": now.Add(-100 * 24 * time.Hour),
+		"3.0": now.Add(-24 * time.Hour),
+	}
+	tests := []struct {
+		newerThan string
+		minAge    string
+		want      []string
+	}{
+		{"", "", versions},
+		{"365d", "", []string{"2.0", "3.0", "4.0"}},
+		{"", "3d", []string{"1.0", "2.0", "4.0"}},
+		{"52w", "48h", []string{"2.0", "4.0"}},
+	}
+	for _, tt := range tests {
+		got := FilterByAge(versions, created, tt.newerThan, tt.minAge)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterByAge(newer_than=%q, min_age=%q) = %v, want %v", tt.newerThan, tt.minAge, got, tt.want)
+		}
+	}
+}
+
+func TestGetVersionsOrder(t *testing.T) {
+	contenders := []string{"1.10.0", "1.2.0", "1.9.0"}
+
+	// Semantic versions are sorted, and otherwise the order given is kept
+	if got := GetVersions(contenders, []string{}, "", "", []string{}, []string{}); !reflect.DeepEqual(got, []string{"1.2.0", "1.9.0", "1.10.0"}) {
+		t.Errorf("GetVersions(%v) = %v, want them sorted", contenders, got)
+	}
+	if got := FilterVersions(contenders, []string{}, "1.2.0", "", []string{}, []string{}); !reflect.DeepEqual(got, []string{"1.2.0", "1.9.0"}) {
+		t.Errorf("FilterVersions(%v) starting at 1.2.0 = %v, want [1.2.0 1.9.0]", contenders, got)
+	}
+}
@@ -0,0 +1,97 @@
+package docker
+
+// Resolve FROM images declared through ARG defaults, e.g.,
+// ARG BASE_IMAGE=ubuntu:22.04 followed by FROM ${BASE_IMAGE}
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/vsoch/uptodate/parsers"
+)
+
+// argReference matches a FROM image that is only a variable, $NAME or ${NAME}
+var argReference = regexp.MustCompile(`^\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))$`)
+
+// GlobalArg is an ARG with a default, declared before the first FROM
+type GlobalArg struct {
+	Command Command
+	Index   int // The index of this name=default in the command values
+	Name    string
+	Value   string // The default without quotes
+	Quote   string
+}
+
+// GlobalArgs returns the ARG defaults declared before the first FROM, by name
+func (d *Dockerfile) GlobalArgs() map[string]GlobalArg {
+	args := map[string]GlobalArg{}
+	froms := d.Cmds["from"]
+	if len(froms) == 0 {
+		return args
+	}
+	for _, cmd := range d.Cmds["arg"] {
+		if cmd.StartLine > froms[0].StartLine {
+			continue
+		}
+		for i, value := range cmd.Value {
+			parts := strings.SplitN(value, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			arg := GlobalArg{Command: cmd, Index: i, Name: parts[0]}
+			arg.Value, arg.Quote = unquote(parts[1])
+			args[arg.Name] = arg
+		}
+	}
+	return args
+}
+
+// resolveFrom returns the global ARG a FROM image is declared through, if any.
+// The default can't itself have a variable.
+func resolveFrom(from Command, args map[string]GlobalArg) (GlobalArg, bool) {
+	match := argReference.FindStringSubmatch(from.Value[0])
+	if match == nil {
+		return GlobalArg{}, false
+	}
+	name := match[1] + match[2]
+	arg, ok := args[name]
+	if !ok || arg.Value == "" || strings.Contains(arg.Value, "$") {
+		return GlobalArg{}, false
+	}
+	return arg, true
+}
+
+// Update returns an update to the ARG line with a new default for the arg
+func (a *GlobalArg) Update(value string) parsers.Update {
+	return tokenUpdate(a.Command, a.Command.Value[a.Index], a.Name+"="+a.Quote+value+a.Quote)
+}
+
+// unquote removes the quotes around an ARG default, returning the quote character used
+func unquote(value string) (string, string) {
+	for _, quote := range []string{"\"", "'"} {
+		if len(value) > 1 && strings.HasPrefix(value, quote) && strings.HasSuffix(value, quote) {
+			return strings.Trim(value, quote), quote
+		}
+	}
+	return value, ""
+}
+
+// unquoteArg returns the values of an ARG with the quotes removed from the first default,
+// so updaters compare the value itself, along with the quote character to put back
+func unquoteArg(values []string) ([]string, string) {
+	parts := strings.SplitN(values[0], "=", 2)
+	if len(parts) != 2 {
+		return values, ""
+	}
+	value, quote := unquote(parts[1])
+	return append([]string{parts[0] + "=" + value}, values[1:]...), quote
+}
+
+// quoteArg puts the quote character of the original back around the default of name=value
+func quoteArg(arg string, quote string) string {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) != 2 || quote == "" {
+		return arg
+	}
+	return parts[0] + "=" + quote + parts[1] + quote
+}
@@ -0,0 +1,106 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+// parseDockerfile writes a Dockerfile to a temporary directory and parses it,
+// the returned function removes the directory
+func parseDockerfile(t *testing.T, content string) (*Dockerfile, func()) {
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "Dockerfile")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	d := Dockerfile{Path: path, Root: dir}
+	d.ParseCommands()
+	return &d, func() { os.RemoveAll(dir) }
+}
+
+func TestGlobalArgs(t *testing.T) {
+	d, cleanup := parseDockerfile(t, "ARG BASE=\"ubuntu:20.04\" OTHER='alpine'\nARG PLAIN=debian:11\nARG EMPTY\nFROM ${BASE}\nARG LATE=ubuntu\n")
+	defer cleanup()
+	args := d.GlobalArgs()
+	tests := []GlobalArg{
+		{Name: "BASE", Value: "ubuntu:20.04", Quote: "\"", Index: 0},
+		{Name: "OTHER", Value: "alpine", Quote: "'", Index: 1},
+		{Name: "PLAIN", Value: "debian:11"},
+	}
+	for _, want := range tests {
+		got, ok := args[want.Name]
+		if !ok || got.Value != want.Value || got.Quote != want.Quote || got.Index != want.Index {
+			t.Errorf("GlobalArgs()[%s] = %+v, want %+v", want.Name, got, want)
+		}
+	}
+
+	// Args without a default, or after the first FROM, are not global defaults
+	for _, name := range []string{"EMPTY", "LATE"} {
+		if _, ok := args[name]; ok {
+			t.Errorf("GlobalArgs() includes %s", name)
+		}
+	}
+}
+
+func TestResolveFrom(t *testing.T) {
+	args := map[string]GlobalArg{
+		"BASE":     {Name: "BASE", Value: "ubuntu:20.04"},
+		"VARIABLE": {Name: "VARIABLE", Value: "ubuntu:${TAG}"},
+	}
+	tests := map[string]string{
+		"${BASE}":        "BASE",
+		"$BASE":          "BASE",
+		"${BASE}-slim":   "",
+		"ubuntu:${BASE}": "",
+		"${VARIABLE}":    "",
+		"${MISSING}":     "",
+		"ubuntu:20.04":   "",
+	}
+	for value, want := range tests {
+		arg, ok := resolveFrom(Command{Value: []string{value}}, args)
+		if ok != (want != "") || arg.Name != want {
+			t.Errorf("resolveFrom(%q) = %q, %v, want %q", value, arg.Name, ok, want)
+		}
+	}
+}
+
+func TestGlobalArgUpdate(t *testing.T) {
+	d, cleanup := parseDockerfile(t, "ARG BASE=\"ubuntu:20.04\" OTHER=1\nFROM ${BASE}\n")
+	defer cleanup()
+	arg := d.GlobalArgs()["BASE"]
+	update := arg.Update("ubuntu:22.04")
+	if update.Updated != "ARG BASE=\"ubuntu:22.04\" OTHER=1" || update.LineNo != 0 {
+		t.Errorf("Update() = %+v, want the quoted default replaced on line 0", update)
+	}
+}
+
+func TestUpdateFromsThroughArg(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	pinned := "sha256:" + strings.Repeat("a", 64)
+	server := newDigestRegistry(pinned, pinned)
+	defer server.Close()
+	image := strings.TrimPrefix(server.URL, "http://") + "/app:1.0"
+
+	// Both FROMs use the ARG, which is updated once, and the FROMs are left as they are
+	d, cleanup := parseDockerfile(t, "ARG BASE="+image+"\nFROM ${BASE} AS one\nFROM $BASE\n")
+	defer cleanup()
+	d.UpdateFroms()
+	if len(d.Updates) != 1 {
+		t.Fatalf("UpdateFroms() = %+v, want one update", d.Updates)
+	}
+	want := "ARG BASE=" + image + "@" + pinned
+	if d.Updates[0].Updated != want || d.Updates[0].LineNo != 0 {
+		t.Errorf("UpdateFroms() = %+v, want %q on line 0", d.Updates[0], want)
+	}
+}
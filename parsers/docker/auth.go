@@ -0,0 +1,186 @@
+package docker
+
+// Credentials for registries, read from the environment or docker config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Credentials for a registry, either a username and password or a token
+type Credentials struct {
+	Username string
+
+	// A password or personal access token
+	Password string
+
+	// An identity (refresh) token to exchange with the auth server
+	IdentityToken string
+
+	// A registry token sent directly as a bearer token
+	RegistryToken string
+}
+
+// IsEmpty determines if we found any credentials
+func (c *Credentials) IsEmpty() bool {
+	return c.Username == "" && c.Password == "" && c.IdentityToken == "" && c.RegistryToken == ""
+}
+
+// dockerAuth is an entry under auths in the docker config
+type dockerAuth struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+	RegistryToken string `json:"registrytoken"`
+}
+
+// dockerConfig is the subset of ~/.docker/config.json we care about
+type dockerConfig struct {
+	Auths       map[string]dockerAuth `json:"auths"`
+	CredHelpers map[string]string     `json:"credHelpers"`
+	CredsStore  string                `json:"credsStore"`
+}
+
+// envRegex matches characters not allowed in an environment variable name
+var envRegex = regexp.MustCompile("[^A-Z0-9]+")
+
+// GetCredentials looks up credentials for a registry host, first in the environment
+// (UPTODATE_REGISTRY_<HOST>_USERNAME, _PASSWORD and _TOKEN) and then the docker config
+func GetCredentials(host string) Credentials {
+	creds := getEnvironmentCredentials(host)
+	if !creds.IsEmpty() {
+		return creds
+	}
+	return getDockerConfigCredentials(host)
+}
+
+// getEnvironmentCredentials reads UPTODATE_REGISTRY_<HOST>_* where ghcr.io is GHCR_IO
+func getEnvironmentCredentials(host string) Credentials {
+	prefix := "UPTODATE_REGISTRY_" + envRegex.ReplaceAllString(strings.ToUpper(host), "_") + "_"
+	return Credentials{
+		Username:      os.Getenv(prefix + "USERNAME"),
+		Password:      os.Getenv(prefix + "PASSWORD"),
+		RegistryToken: os.Getenv(prefix + "TOKEN"),
+	}
+}
+
+// getDockerConfigCredentials reads the docker config, calling out to credential helpers
+func getDockerConfigCredentials(host string) Credentials {
+	creds := Credentials{}
+
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return creds
+		}
+		configDir = filepath.Join(home, ".docker")
+	}
+	content, err := ioutil.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return creds
+	}
+	conf := dockerConfig{}
+	if err := json.Unmarshal(content, &conf); err != nil {
+		fmt.Printf("Cannot parse docker config in %s: %s\n", configDir, err)
+		return creds
+	}
+
+	// A registry specific helper takes precedence over everything
+	for _, key := range configKeys(host) {
+		if helper, ok := conf.CredHelpers[key]; ok {
+			return getHelperCredentials(helper, key)
+		}
+	}
+
+	// Then a credential store for all registries
+	if conf.CredsStore != "" {
+		for _, key := range configKeys(host) {
+			creds = getHelperCredentials(conf.CredsStore, key)
+			if !creds.IsEmpty() {
+				return creds
+			}
+		}
+	}
+
+	// And finally credentials saved in the file
+	for _, key := range configKeys(host) {
+		auth, ok := conf.Auths[key]
+		if !ok {
+			continue
+		}
+		creds = Credentials{Username: auth.Username, Password: auth.Password,
+			IdentityToken: auth.IdentityToken, RegistryToken: auth.RegistryToken}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err == nil && strings.Contains(string(decoded), ":") {
+				parts := strings.SplitN(string(decoded), ":", 2)
+				creds.Username = parts[0]
+				creds.Password = parts[1]
+			}
+		}
+		return creds
+	}
+	return creds
+}
+
+// configKeys returns the keys a registry host might be saved under in the docker config
+func configKeys(host string) []string {
+	if host == "docker.io" || host == "index.docker.io" || host == "registry-1.docker.io" {
+		return []string{"https://index.docker.io/v1/", "index.docker.io", "docker.io", "registry-1.docker.io"}
+	}
+	return []string{host, "https://" + host, "http://" + host}
+}
+
+// CredentialHelperTimeout is how long a credential helper can take before it is stopped
+var CredentialHelperTimeout = 30 * time.Second
+
+// getHelperCredentials runs docker-credential-<helper> get for a server
+func getHelperCredentials(helper string, server string) Credentials {
+	creds := Credentials{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CredentialHelperTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	// Helpers exit non-zero when they don't know the server, which is fine
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			fmt.Printf("docker-credential-%s did not finish within %s, continuing without credentials.\n", helper, CredentialHelperTimeout)
+		}
+		return creds
+	}
+	response := struct {
+		ServerURL string `json:"ServerURL"`
+		Username  string `json:"Username"`
+		Secret    string `json:"Secret"`
+	}{}
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		fmt.Printf("Cannot parse response from docker-credential-%s: %s\n", helper, err)
+		return creds
+	}
+
+	// This username is the convention for an identity token
+	if response.Username == "<token>" {
+		creds.IdentityToken = response.Secret
+	} else {
+		creds.Username = response.Username
+		creds.Password = response.Secret
+	}
+	return creds
+}
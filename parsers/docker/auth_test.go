@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestGetHelperCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helpers are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	helpers := map[string]string{
+		"basic":   `echo '{"ServerURL": "ghcr.io", "Username": "dinosaur", "Secret": "pancakes"}'`,
+		"token":   `echo '{"ServerURL": "ghcr.io", "Username": "<token>", "Secret": "identity"}'`,
+		"unknown": `echo "credentials not found in native keychain"; exit 1`,
+		"slow":    `exec sleep 10`,
+	}
+	for name, script := range helpers {
+		path := filepath.Join(dir, "docker-credential-"+name)
+		if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	timeout := CredentialHelperTimeout
+	CredentialHelperTimeout = 100 * time.Millisecond
+	defer func() { CredentialHelperTimeout = timeout }()
+
+	tests := []struct {
+		helper string
+		want   Credentials
+	}{
+		{"basic", Credentials{Username: "dinosaur", Password: "pancakes"}},
+		{"token", Credentials{IdentityToken: "identity"}},
+		{"unknown", Credentials{}},
+		{"missing", Credentials{}},
+		{"slow", Credentials{}},
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		if got := getHelperCredentials(tt.helper, "ghcr.io"); got != tt.want {
+			t.Errorf("getHelperCredentials(%q) = %+v, want %+v", tt.helper, got, tt.want)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("getHelperCredentials(%q) took %s, the helper was not stopped", tt.helper, elapsed)
+		}
+	}
+}
@@ -58,7 +58,11 @@ func (s *DockerBasesParser) Parse(basesPath string, providedPaths []string, chan
 
 	// Look at each found path, parse into build matrix
 	for _, subpath := range paths {
-		conf := config.Load(subpath)
+		conf, err := config.Load(subpath)
+		if err != nil {
+			log.Printf("Skipping %s, %s\n", subpath, err)
+			continue
+		}
 
 		// We must have a DockerBuild to continue! This checks against an empty one
 		if reflect.DeepEqual(conf.DockerBuild, config.DockerBuild{}) {
@@ -150,11 +154,11 @@ func generateBasesBuildCommand(buildargs map[string]string, dockerfile string, l
 	command := "docker build -f " + dockerfile
 
 	// Add each buildarg and labels
-	for key, value := range buildargs {
-		command += " --build-arg " + key + "=" + value
+	for _, key := range utils.SortedKeys(buildargs) {
+		command += " --build-arg " + key + "=" + buildargs[key]
 	}
-	for key, value := range labels {
-		command += " --label " + key + "=" + value
+	for _, key := range utils.SortedKeys(labels) {
+		command += " --label " + key + "=" + labels[key]
 	}
 	return command
 }
@@ -29,6 +29,21 @@ type ContainerNamer struct {
 	Type string
 }
 
+// Segment is the slug as part of a container name. An image (e.g., localhost:5000/team/app)
+// keeps its repository path without the registry, with / replaced (team-app), so registries
+// and ports don't end up in the name and images in different namespaces don't collide
+func (n *ContainerNamer) Segment() string {
+	ref, err := ParseReference(n.Slug)
+	if err != nil {
+		return n.Slug
+	}
+	repository := ref.Repository
+	if ref.Registry == "docker.io" {
+		repository = strings.TrimPrefix(repository, "library/")
+	}
+	return strings.Replace(repository, "/", "-", -1)
+}
+
 type Label struct {
 	Key   string
 	Type  string
@@ -66,7 +81,11 @@ func (s *DockerBuildParser) Parse(providedPaths []string, changesOnly bool, bran
 
 	// Look at each found path, parse into build matrix
 	for _, subpath := range paths {
-		conf := config.Load(subpath)
+		conf, err := config.Load(subpath)
+		if err != nil {
+			log.Printf("Skipping %s, %s\n", subpath, err)
+			continue
+		}
 
 		// We must have a DockerBuild to continue! This checks against an empty one
 		if reflect.DeepEqual(conf.DockerBuild, config.DockerBuild{}) {
@@ -215,10 +234,15 @@ func getCurrentValues(registry string, matrix []map[string]string, namingLookup
 
 		// We can look up variables in the config
 		containerName := generateContainerName(registry, entry, namingLookup, dirname, containerBasename)
-		withoutTag := strings.SplitN(containerName, ":", 2)[0]
+		ref, err := ParseReference(containerName)
+		if err != nil {
+			fmt.Printf("Cannot parse container name %s: %s\n", containerName, err)
+			continue
+		}
+		withoutTag := ref.Name
 
 		// Get a list of known tags to start
-		tags := GetImageTags(withoutTag)
+		tags := GetImageTags(withoutTag, 0)
 
 		if len(tags) == 0 {
 			fmt.Printf("Container %s does not have any tags, skipping lookup.", withoutTag)
@@ -279,7 +303,7 @@ func getLatestValues(registry string, matrix []map[string]string, namingLookup m
 				if cached, ok := cache[namer.Slug+":"+tag]; ok {
 					currentValues[containerName][namer.Key] = cached
 				} else {
-					updatedContainer := getUpdatedContainer(namer.Slug + ":" + tag)
+					updatedContainer := getUpdatedContainer(namer.Slug+":"+tag, DigestPolicy{Mode: DigestIndex})
 					currentValues[containerName][namer.Key] = updatedContainer
 					cache[namer.Slug+":"+tag] = updatedContainer
 				}
@@ -290,6 +314,16 @@ func getLatestValues(registry string, matrix []map[string]string, namingLookup m
 	return currentValues
 }
 
+// getVersionLister returns the updater for a build arg type, if it can list versions
+func getVersionLister(source string) (parsers.VersionLister, bool) {
+	updater, ok := parsers.GetUpdater(source)
+	if !ok {
+		return nil, false
+	}
+	lister, ok := updater.(parsers.VersionLister)
+	return lister, ok
+}
+
 // GetBuildMatrix: Upper level function to get a build matrix, either from config or generation
 func GetBuildMatrix(conf config.Conf, namingLookup *map[string][]ContainerNamer, namingList *[]ContainerNamer, excludes *map[string][]string) []map[string]string {
 
@@ -316,7 +350,15 @@ func GetBuildMatrix(conf config.Conf, namingLookup *map[string][]ContainerNamer,
 		}
 	}
 
-	for key, buildarg := range conf.DockerBuild.BuildArgs {
+	// Sort the build args so the matrix is the same between runs
+	keys := []string{}
+	for key := range conf.DockerBuild.BuildArgs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		buildarg := conf.DockerBuild.BuildArgs[key]
 
 		// Skip those that aren't in matrix, if matrix predefined
 		if len(allowKeys) > 0 && !utils.IncludesString(key, allowKeys) {
@@ -339,7 +381,16 @@ func GetBuildMatrix(conf config.Conf, namingLookup *map[string][]ContainerNamer,
 			namer.Type = "spack"
 			(*namingLookup)["tag"] = append((*namingLookup)["tag"], namer)
 			(*namingList) = append((*namingList), namer)
+		} else if lister, ok := getVersionLister(buildarg.Type); ok {
+			result := parseListedBuildArg(key, buildarg, lister)
+			vars = append(vars, result...)
+			namer.Type = buildarg.Type
+			(*namingLookup)["tag"] = append((*namingLookup)["tag"], namer)
+			(*namingList) = append((*namingList), namer)
 		} else {
+			if buildarg.Type != "" {
+				fmt.Printf("Unknown type %s for %s, and no %s%s plugin found, using values\n", buildarg.Type, key, parsers.PluginPrefix, buildarg.Type)
+			}
 			result := parseBuildArg(key, buildarg)
 			vars = append(vars, result...)
 			namer.Type = "manual"
@@ -496,7 +547,7 @@ func generateContainerName(registry string, buildargs map[string]string, lookup
 		containerName = basename
 		// For each known container variable, this gets added to the container name
 		for _, namer := range lookup["container"] {
-			containerName = containerName + "-" + namer.Slug + "-" + buildargs[namer.Key]
+			containerName = containerName + "-" + namer.Segment() + "-" + buildargs[namer.Key]
 		}
 
 	}
@@ -510,7 +561,7 @@ func generateContainerName(registry string, buildargs map[string]string, lookup
 	if len(lookup["tag"]) > 0 {
 		containerName += ":"
 		for i, namer := range lookup["tag"] {
-			containerName = containerName + namer.Slug + "-" + buildargs[namer.Key]
+			containerName = containerName + namer.Segment() + "-" + buildargs[namer.Key]
 			if i != len(lookup["tag"])-1 {
 				containerName = containerName + "-"
 			}
@@ -530,11 +581,11 @@ func generateBuildCommand(buildargs map[string]string, dockerfile string, labels
 	command := "docker build -f " + filename
 
 	// Add each buildarg and labels
-	for key, value := range buildargs {
-		command += " --build-arg " + key + "=" + value
+	for _, key := range utils.SortedKeys(buildargs) {
+		command += " --build-arg " + key + "=" + buildargs[key]
 	}
-	for key, value := range labels {
-		command += " --label " + key + "=" + value
+	for _, key := range utils.SortedKeys(labels) {
+		command += " --label " + key + "=" + labels[key]
 	}
 	return command
 }
@@ -549,8 +600,8 @@ func generateBuildDescription(buildargs map[string]string, dockerfile string) st
 	description := dirname
 
 	// Add each buildarg
-	for key, value := range buildargs {
-		description += " " + key + ":" + value
+	for _, key := range utils.SortedKeys(buildargs) {
+		description += " " + key + ":" + buildargs[key]
 	}
 	return description
 }
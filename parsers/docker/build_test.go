@@ -0,0 +1,40 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestContainerNamerSegment(t *testing.T) {
+	tests := []struct {
+		slug string
+		want string
+	}{
+		{"ubuntu", "ubuntu"},
+		{"docker.io/library/ubuntu", "ubuntu"},
+		{"nvidia/cuda", "nvidia-cuda"},
+		{"localhost:5000/team/app", "team-app"},
+		{"ghcr.io/org/app", "org-app"},
+		{"ghcr.io/other/app", "other-app"},
+		{"spack_version", "spack_version"},
+		{"Not An Image", "Not An Image"},
+	}
+	for _, tt := range tests {
+		namer := ContainerNamer{Slug: tt.slug}
+		if got := namer.Segment(); got != tt.want {
+			t.Errorf("Segment() for %q = %q, want %q", tt.slug, got, tt.want)
+		}
+	}
+}
+
+func TestGetVersionLister(t *testing.T) {
+
+	// Registered updaters that list versions are build arg types
+	if _, ok := getVersionLister("pypi"); !ok {
+		t.Errorf("getVersionLister(pypi) did not find a lister")
+	}
+	for _, source := range []string{"", "manual", "github", "not-a-source"} {
+		if _, ok := getVersionLister(source); ok {
+			t.Errorf("getVersionLister(%q) found a lister", source)
+		}
+	}
+}
@@ -4,7 +4,6 @@ package docker
 
 import (
 	"log"
-	"strings"
 
 	"github.com/vsoch/uptodate/config"
 	"github.com/vsoch/uptodate/parsers"
@@ -39,20 +38,32 @@ func parseContainerBuildArg(key string, buildarg config.BuildArg) []parsers.Buil
 
 	// The container is required to have the name
 	if buildarg.Name == "" {
-		log.Fatalf("A container buildarg requires a name: %s\n", buildarg)
+		log.Fatalf("A container buildarg requires a name: %v\n", buildarg)
+	}
+
+	ref, err := ParseReference(buildarg.Name)
+	if err != nil {
+		log.Fatalf("Cannot parse container buildarg name %s: %s\n", buildarg.Name, err)
 	}
 
 	// If the name has a tag, we just update the version. No further parsing
-	if strings.Contains(buildarg.Name, ":") {
+	if ref.Tag != "" {
 		fromValue := []string{buildarg.Name}
-		update := UpdateFrom(fromValue)
+		update := UpdateFrom(fromValue, DigestPolicy{Mode: DigestIndex})
 		newVar := parsers.BuildVariable{Name: key, Values: []string{update.Updated}}
 		vars = append(vars, newVar)
 
 		// Otherwise we want to be generating a list of tags (versions)
 	} else {
-		versions := GetVersions(buildarg.Name, buildarg.Filter, buildarg.StartAt, buildarg.EndAt,
-			buildarg.Skips, buildarg.Includes)
+		var versions []string
+		if label := buildarg.GetVersionLabel(); label != "" {
+			versions = GetLabelVersions(buildarg.Name, label, buildarg.Filter, buildarg.StartAt, buildarg.EndAt,
+				buildarg.Skips, buildarg.Includes, buildarg.MaxTags, buildarg.LabelMaxTags)
+		} else {
+			versions = GetVersions(buildarg.Name, buildarg.Filter, buildarg.StartAt, buildarg.EndAt,
+				buildarg.Skips, buildarg.Includes, buildarg.MaxTags)
+		}
+		versions = FilterVersionsByAge(buildarg.Name, versions, buildarg.NewerThan, buildarg.MinAge)
 		newVar := parsers.BuildVariable{Name: key, Values: versions}
 		vars = append(vars, newVar)
 
@@ -73,3 +84,27 @@ func parseSpackBuildArg(key string, buildarg config.BuildArg) []parsers.BuildVar
 	vars := []parsers.BuildVariable{newVar}
 	return vars
 }
+
+// parseListedBuildArg parses a build arg with versions from an updater that can list
+// them (e.g., a plugin). The name is the package, and the params are passed along.
+func parseListedBuildArg(key string, buildarg config.BuildArg, lister parsers.VersionLister) []parsers.BuildVariable {
+
+	contenders, err := lister.ListVersions(buildarg.Name, buildarg.Params)
+	if err != nil {
+		log.Fatalf("Cannot get versions for %s (%s): %s\n", key, buildarg.Type, err)
+	}
+
+	// Get versions based on user preferences, the lister has already sorted them
+	versions := parsers.FilterVersions(contenders, buildarg.Filter, buildarg.StartAt, buildarg.EndAt, buildarg.Skips, buildarg.Includes)
+
+	// Creation times can take a lookup per version, so only get them if we need them
+	if buildarg.NewerThan != "" || buildarg.MinAge != "" {
+		created, err := lister.Created(buildarg.Name, buildarg.Params, versions)
+		if err != nil {
+			log.Fatalf("Cannot get creation times for %s (%s): %s\n", key, buildarg.Type, err)
+		}
+		versions = parsers.FilterByAge(versions, created, buildarg.NewerThan, buildarg.MinAge)
+	}
+	newVar := parsers.BuildVariable{Name: key, Values: versions}
+	return []parsers.BuildVariable{newVar}
+}
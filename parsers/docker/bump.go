@@ -0,0 +1,207 @@
+package docker
+
+// Bump the tag of an image to the newest tag allowed by a policy,
+// e.g., python:3.10-slim to python:3.12-slim for a minor bump
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vsoch/uptodate/config"
+	"github.com/vsoch/uptodate/parsers"
+)
+
+// Bump policies
+const (
+	BumpPatch = "patch"
+	BumpMinor = "minor"
+	BumpMajor = "major"
+	BumpRegex = "regex"
+)
+
+// tagVersionRegex splits a tag into a prefix (v), a numeric version, and a suffix (e.g., -slim)
+var tagVersionRegex = regexp.MustCompile(`^(v?)([0-9]+(?:[.][0-9]+)*)(.*)$`)
+
+// TagPolicy decides which newer tags an image can move to
+type TagPolicy struct {
+	Bump    string
+	Pattern string // For a regex policy, matched against the version without a suffix
+	Filter  string // If set, tags must also match this regular expression
+}
+
+// ParseTagPolicy parses patch, minor, major, or regex:<pattern>
+func ParseTagPolicy(value string) (TagPolicy, error) {
+	policy := TagPolicy{Bump: value}
+	if strings.HasPrefix(value, BumpRegex+":") {
+		policy = TagPolicy{Bump: BumpRegex, Pattern: strings.TrimPrefix(value, BumpRegex+":")}
+		if _, err := regexp.Compile(policy.Pattern); err != nil {
+			return policy, fmt.Errorf("%s is not a valid regular expression: %s", policy.Pattern, err)
+		}
+		return policy, nil
+	}
+	if value != BumpPatch && value != BumpMinor && value != BumpMajor {
+		return policy, fmt.Errorf("%s is not a bump policy, choices are %s, %s, %s, or %s:<pattern>", value, BumpPatch, BumpMinor, BumpMajor, BumpRegex)
+	}
+	return policy, nil
+}
+
+// tagVersion is a tag split into a numeric version and what surrounds it
+type tagVersion struct {
+	Prefix  string
+	Numbers []int
+	Suffix  string
+}
+
+// parseTagVersion splits a tag like 3.10-slim, it must start with a number (or v)
+func parseTagVersion(tag string) (tagVersion, bool) {
+	match := tagVersionRegex.FindStringSubmatch(tag)
+	if match == nil {
+		return tagVersion{}, false
+	}
+	version := tagVersion{Prefix: match[1], Suffix: match[3]}
+	for _, part := range strings.Split(match[2], ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return tagVersion{}, false
+		}
+		version.Numbers = append(version.Numbers, number)
+	}
+	return version, true
+}
+
+// compareNumbers compares versions by number, a shorter version is older
+func compareNumbers(a []int, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// filter returns a regular expression for tags the current tag can move to.
+// The prefix and suffix are kept, and so is the number of version parts.
+func (p *TagPolicy) filter(current tagVersion) (string, error) {
+	prefix := "^" + regexp.QuoteMeta(current.Prefix)
+	suffix := regexp.QuoteMeta(current.Suffix) + "$"
+	if p.Bump == BumpRegex {
+		return prefix + "(?:" + p.Pattern + ")" + suffix, nil
+	}
+
+	// How many leading numbers have to stay the same
+	fixed := map[string]int{BumpMajor: 0, BumpMinor: 1, BumpPatch: 2}[p.Bump]
+	if len(current.Numbers) <= fixed {
+		return "", fmt.Errorf("the tag does not have a %s version", p.Bump)
+	}
+	parts := []string{}
+	for i, number := range current.Numbers {
+		if i < fixed {
+			parts = append(parts, strconv.Itoa(number))
+		} else {
+			parts = append(parts, "[0-9]+")
+		}
+	}
+	return prefix + strings.Join(parts, "[.]") + suffix, nil
+}
+
+// NewestTag returns the newest tag of an image allowed by the policy, if it is newer than the tag
+func (p *TagPolicy) NewestTag(name string, tag string) (string, error) {
+	current, ok := parseTagVersion(tag)
+	if !ok {
+		return "", fmt.Errorf("%s is not a version we can bump", tag)
+	}
+	filter, err := p.filter(current)
+	if err != nil {
+		return "", err
+	}
+
+	isMatch, err := regexp.Compile(p.Filter)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a valid filter: %s", p.Filter, err)
+	}
+
+	// Only tags with the same prefix and suffix (and matching the filter) are candidates
+	candidates := []string{}
+	for _, candidate := range GetImageTags(name, 0) {
+		version, ok := parseTagVersion(candidate)
+		if ok && version.Suffix == current.Suffix && version.Prefix == current.Prefix && isMatch.MatchString(candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	// Filter the tags as we would versions, and find the newest
+	newest := tag
+	newestVersion := current
+	for _, candidate := range parsers.GetVersions(candidates, []string{filter}, "", "", []string{}, []string{}) {
+		version, _ := parseTagVersion(candidate)
+		if compareNumbers(version.Numbers, newestVersion.Numbers) > 0 {
+			newest, newestVersion = candidate, version
+		}
+	}
+	if newest == tag {
+		return "", nil
+	}
+	return newest, nil
+}
+
+// imageBump finds the bump policy for an image, from a directive or else the repository config
+func imageBump(ref Reference, directives map[string]string, images []config.Image) string {
+	if bump, ok := directives["bump"]; ok {
+		return bump
+	}
+	for _, image := range images {
+		other, err := ParseReference(image.Name)
+		if err == nil && ref.SameRepository(other) {
+			return image.Bump
+		}
+	}
+	return ""
+}
+
+// bumpTag returns the image with the newest tag allowed by its bump policy (and filter),
+// or unchanged if it has no policy, is pinned to its tag, or there isn't a newer tag
+func (d *Dockerfile) bumpTag(image string, directives map[string]string) string {
+	ref, err := ParseReference(image)
+	if err != nil || ref.Tag == "" {
+		return image
+	}
+
+	// Pinning the digest keeps the tag, regardless of a policy
+	if pin, ok := directives["pin"]; ok {
+		if pin != "digest" {
+			fmt.Printf("Unknown pin %s for %s, only pin=digest is supported.\n", pin, image)
+		}
+		return image
+	}
+
+	// A filter alone moves to the newest matching tag
+	bump := imageBump(ref, directives, d.Images)
+	filter := directives["filter"]
+	if bump == "" && filter != "" {
+		bump = BumpMajor
+	}
+	if bump == "" {
+		return image
+	}
+	policy, err := ParseTagPolicy(bump)
+	if err != nil {
+		fmt.Printf("Cannot bump %s: %s\n", image, err)
+		return image
+	}
+	policy.Filter = filter
+	newest, err := policy.NewestTag(ref.Name, ref.Tag)
+	if err != nil {
+		fmt.Printf("Cannot bump %s: %s\n", image, err)
+		return image
+	}
+	if newest == "" {
+		return image
+	}
+	fmt.Printf("Bumping %s to %s (%s)\n", image, ref.Name+":"+newest, bump)
+	return ref.Name + ":" + newest
+}
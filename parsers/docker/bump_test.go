@@ -0,0 +1,129 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vsoch/uptodate/config"
+	"github.com/vsoch/uptodate/utils"
+)
+
+func TestParseTagPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    TagPolicy
+		wantErr bool
+	}{
+		{"patch", TagPolicy{Bump: BumpPatch}, false},
+		{"minor", TagPolicy{Bump: BumpMinor}, false},
+		{"major", TagPolicy{Bump: BumpMajor}, false},
+		{"regex:3[.]1[0-9]", TagPolicy{Bump: BumpRegex, Pattern: "3[.]1[0-9]"}, false},
+		{"regex:3[", TagPolicy{}, true},
+		{"latest", TagPolicy{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTagPolicy(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTagPolicy(%q) = %+v, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTagPolicy(%q) = %+v, %v, want %+v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseTagVersion(t *testing.T) {
+	version, ok := parseTagVersion("v3.10.2-slim-bullseye")
+	if !ok || version.Prefix != "v" || version.Suffix != "-slim-bullseye" || len(version.Numbers) != 3 || version.Numbers[1] != 10 {
+		t.Errorf("parseTagVersion(v3.10.2-slim-bullseye) = %+v, %v", version, ok)
+	}
+	for _, tag := range []string{"latest", "slim-3.10", ""} {
+		if version, ok := parseTagVersion(tag); ok {
+			t.Errorf("parseTagVersion(%q) = %+v, want no version", tag, version)
+		}
+	}
+}
+
+func TestNewestTag(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	tags := []string{"3.9-slim", "3.10-slim", "3.10.4-slim", "3.10.6-slim", "3.11-slim", "3.12", "4.0-slim", "latest", "v3.13-slim"}
+	server, _ := newTestRegistry(tags)
+	defer server.Close()
+	name := strings.TrimPrefix(server.URL, "http://") + "/team/app"
+
+	tests := []struct {
+		bump    string
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{"minor", "3.10-slim", "3.11-slim", false},
+		{"major", "3.10-slim", "4.0-slim", false},
+		{"patch", "3.10.4-slim", "3.10.6-slim", false},
+		{"regex:3[.]1[0-9]", "3.9-slim", "3.11-slim", false},
+		{"minor", "4.0-slim", "", false},
+		{"patch", "3.10-slim", "", true},
+		{"minor", "latest", "", true},
+	}
+	for _, tt := range tests {
+		policy, err := ParseTagPolicy(tt.bump)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := policy.NewestTag(name, tt.tag)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NewestTag(%s) with %s = %q, %v, want %q", tt.tag, tt.bump, got, err, tt.want)
+		}
+	}
+}
+
+func TestImageBump(t *testing.T) {
+	images := []config.Image{{Name: "python", Bump: "minor"}, {Name: "ghcr.io/org/app", Bump: "major"}}
+	tests := []struct {
+		image      string
+		directives map[string]string
+		want       string
+	}{
+		{"python:3.10-slim", map[string]string{}, "minor"},
+		{"docker.io/library/python:3.10", map[string]string{}, "minor"},
+		{"python:3.10", map[string]string{"bump": "patch"}, "patch"},
+		{"ghcr.io/org/app:1.0", map[string]string{}, "major"},
+		{"ghcr.io/other/app:1.0", map[string]string{}, ""},
+	}
+	for _, tt := range tests {
+		ref, err := ParseReference(tt.image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := imageBump(ref, tt.directives, images); got != tt.want {
+			t.Errorf("imageBump(%s, %v) = %q, want %q", tt.image, tt.directives, got, tt.want)
+		}
+	}
+}
+
+func TestBumpTag(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	server, _ := newTestRegistry([]string{"3.10-slim", "3.11-slim", "3.12-slim"})
+	defer server.Close()
+	name := strings.TrimPrefix(server.URL, "http://") + "/team/app"
+
+	d := Dockerfile{Images: []config.Image{{Name: name, Bump: "minor"}}}
+	if got := d.bumpTag(name+":3.10-slim", map[string]string{}); got != name+":3.12-slim" {
+		t.Errorf("bumpTag() = %q, want %s:3.12-slim", got, name)
+	}
+
+	// Without a policy, or a tag, the image stays as it is
+	d.Images = []config.Image{}
+	for _, image := range []string{name + ":3.10-slim", name} {
+		if got := d.bumpTag(image, map[string]string{}); got != image {
+			t.Errorf("bumpTag(%q) without a policy = %q", image, got)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package docker
+
+// Check that digests pinned in FROM statements still resolve
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vsoch/uptodate/parsers/git"
+	"github.com/vsoch/uptodate/utils"
+)
+
+// DigestCheck is the result of checking one pinned FROM
+type DigestCheck struct {
+	Filename string `json:"filename"`
+	LineNo   int    `json:"line"`
+	Image    string `json:"image"`
+
+	// The pinned digest still resolves in the registry
+	Found bool `json:"found"`
+
+	// A pin without a tag (image@sha256:...) has no tag to track
+	Tracked bool `json:"tracked"`
+
+	// The tag still exists, and still points to the pinned digest
+	TagFound bool `json:"tag_found"`
+	Current  bool `json:"current"`
+
+	// Days since the pinned image was created, -1 if unknown
+	AgeDays int `json:"age_days"`
+}
+
+// Problems returns a human readable list of issues with the pin
+func (c *DigestCheck) Problems() []string {
+	problems := []string{}
+	if !c.Found {
+		problems = append(problems, "pinned digest no longer exists")
+	}
+	if !c.Tracked {
+		return problems
+	}
+	if !c.TagFound {
+		problems = append(problems, "tag no longer exists")
+	} else if !c.Current {
+		problems = append(problems, "tag has moved to a new digest")
+	}
+	return problems
+}
+
+// CheckDigest checks a single pinned reference
+func CheckDigest(ref Reference) DigestCheck {
+	check := DigestCheck{Image: ref.String(), AgeDays: -1}
+
+	// Does the pinned digest still resolve? We always ask the registry
+	found, err := DefaultRegistry.HasManifest(ref.Name, ref.Digest)
+	check.Found = found
+	if err != nil {
+		fmt.Printf("Cannot check %s: %s\n", ref.String(), err)
+	}
+
+	// The image config tells us when the image was created
+	if check.Found {
+		imageConf := GetImageConfig(ref.Name + "@" + ref.Digest)
+		if !imageConf.Created.IsZero() {
+			check.AgeDays = int(time.Since(imageConf.Created).Hours() / 24)
+		}
+	}
+
+	// Without a tag, there is nothing to compare the digest to
+	if ref.Tag == "" {
+		return check
+	}
+	check.Tracked = true
+
+	// Does the tag still exist, and point to the digest (or an index that includes it)?
+	manifest, digest, err := DefaultRegistry.GetManifest(ref.Name, ref.Tag)
+	check.TagFound = err == nil
+	if err == nil {
+		check.Current = digest == ref.Digest
+		for _, descriptor := range manifest.Manifests {
+			if descriptor.Digest == ref.Digest {
+				check.Current = true
+			}
+		}
+	} else if !IsNotFound(err) {
+		fmt.Printf("Cannot check %s: %s\n", ref.Name+":"+ref.Tag, err)
+	}
+	return check
+}
+
+// CheckDigests checks every pinned FROM in a Dockerfile
+func (d *Dockerfile) CheckDigests() []DigestCheck {
+	checks := []DigestCheck{}
+	if len(d.Cmds) == 0 {
+		d.ParseCommands()
+	}
+	args := d.GlobalArgs()
+	seen := map[string]bool{}
+	for _, from := range d.Cmds["from"] {
+
+		// A FROM declared through an ARG is pinned in the ARG default, check it once
+		image, lineNo := from.Value[0], from.StartLine
+		if arg, ok := resolveFrom(from, args); ok {
+			if seen[arg.Name] {
+				continue
+			}
+			seen[arg.Name] = true
+			image, lineNo = arg.Value, arg.Command.StartLine
+		}
+		ref, err := ParseReference(image)
+		if err != nil || ref.Digest == "" {
+			continue
+		}
+		check := CheckDigest(ref)
+		check.Filename = d.Path
+		check.LineNo = lineNo
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// CheckDigests finds Dockerfiles under a path and checks their pinned digests.
+// The number of pins with a digest that no longer exists is returned.
+func (s *DockerfileParser) CheckDigests(path string, changesOnly bool, branch string) int {
+
+	// Find Dockerfiles in path and allow prefixes
+	paths, _ := utils.RecursiveFind(path, "Dockerfile", true)
+	if changesOnly {
+		changed := git.GetChangedFilesStrings(path, branch)
+		paths = utils.FindOverlap(paths, changed)
+	}
+	if len(paths) == 0 {
+		fmt.Println("No changes to parse.")
+	}
+
+	checks := []DigestCheck{}
+	for _, subpath := range paths {
+		dockerfile := Dockerfile{Path: subpath, Root: path}
+		checks = append(checks, dockerfile.CheckDigests()...)
+	}
+
+	// Show each pin, flagging any with problems
+	missing := 0
+	flagged := 0
+	for _, check := range checks {
+		age := "unknown age"
+		if check.AgeDays >= 0 {
+			age = strconv.Itoa(check.AgeDays) + " days old"
+		}
+		problems := check.Problems()
+		status := "ok"
+		if !check.Tracked {
+			status = "not tracked"
+		}
+		if len(problems) > 0 {
+			status = strings.Join(problems, ", ")
+			flagged += 1
+		}
+		if !check.Found {
+			missing += 1
+		}
+		fmt.Printf("%s:%d %s\n    %s, %s\n", check.Filename, check.LineNo, check.Image, age, status)
+	}
+
+	fmt.Println("\n  ⭐️ Digest Check ⭐️")
+	fmt.Printf("     Checked: %d\n", len(checks))
+	fmt.Printf("     Flagged: %d\n", flagged)
+	fmt.Printf("     Missing: %d\n", missing)
+
+	// If we are running in a GitHub Action, set the outputs
+	if utils.IsGitHubAction() {
+		outJson, _ := json.Marshal(checks)
+		utils.WriteGitHubOutput("dockerfile_digests", string(outJson))
+		utils.WriteGitHubOutput("dockerfile_digests_missing", strconv.Itoa(missing))
+	}
+	return missing
+}
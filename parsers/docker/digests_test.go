@@ -0,0 +1,110 @@
+package docker
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+// newDigestRegistry serves app, where tag 1.0 is the pinned digest and moved is another
+func newDigestRegistry(pinned string, other string) *httptest.Server {
+	created := time.Now().Add(-72 * time.Hour).UTC().Format(time.RFC3339)
+	configDigest := "sha256:" + strings.Repeat("f", 64)
+	manifests := map[string]string{"1.0": pinned, pinned: pinned, "moved": other, other: other}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/app/blobs/"+configDigest {
+			w.Write([]byte(`{"created": "` + created + `"}`))
+			return
+		}
+		digest, ok := manifests[strings.TrimPrefix(r.URL.Path, "/v2/app/manifests/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", MediaTypeOCIManifest)
+		w.Header().Set("Docker-Content-Digest", digest)
+		json.NewEncoder(w).Encode(Manifest{SchemaVersion: 2, MediaType: MediaTypeOCIManifest,
+			Config: Descriptor{Digest: configDigest}})
+	}))
+}
+
+func TestCheckDigest(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	pinned := "sha256:" + strings.Repeat("a", 64)
+	other := "sha256:" + strings.Repeat("b", 64)
+	missing := "sha256:" + strings.Repeat("c", 64)
+	server := newDigestRegistry(pinned, other)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name     string
+		value    string
+		want     DigestCheck
+		problems []string
+	}{
+		{"current", host + "/app:1.0@" + pinned, DigestCheck{Found: true, Tracked: true, TagFound: true, Current: true, AgeDays: 3}, []string{}},
+		{"moved", host + "/app:moved@" + pinned, DigestCheck{Found: true, Tracked: true, TagFound: true, AgeDays: 3}, []string{"tag has moved to a new digest"}},
+		{"tag gone", host + "/app:gone@" + pinned, DigestCheck{Found: true, Tracked: true, AgeDays: 3}, []string{"tag no longer exists"}},
+		{"digest gone", host + "/app:1.0@" + missing, DigestCheck{Tracked: true, TagFound: true, AgeDays: -1}, []string{"pinned digest no longer exists", "tag has moved to a new digest"}},
+		{"not tracked", host + "/app@" + pinned, DigestCheck{Found: true, AgeDays: 3}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseReference(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := CheckDigest(ref)
+			tt.want.Image = ref.String()
+			if got != tt.want {
+				t.Errorf("CheckDigest(%s) = %+v, want %+v", tt.value, got, tt.want)
+			}
+			if problems := got.Problems(); !reflect.DeepEqual(problems, tt.problems) {
+				t.Errorf("Problems() for %s = %v, want %v", tt.value, problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestCheckDigestsMissing(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	pinned := "sha256:" + strings.Repeat("a", 64)
+	missing := "sha256:" + strings.Repeat("c", 64)
+	server := newDigestRegistry(pinned, pinned)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	content := "FROM " + host + "/app:1.0@" + pinned + " AS one\nFROM " + host + "/app:1.0@" + missing + "\nFROM " + host + "/app:1.0\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only FROMs with a digest are checked, and one of them is gone
+	dockerfile := Dockerfile{Path: filepath.Join(dir, "Dockerfile"), Root: dir}
+	if checks := dockerfile.CheckDigests(); len(checks) != 2 || checks[0].LineNo != 1 || checks[1].LineNo != 2 {
+		t.Errorf("CheckDigests() = %+v, want checks for lines 1 and 2", checks)
+	}
+	parser := DockerfileParser{}
+	if got := parser.CheckDigests(dir, false, "main"); got != 1 {
+		t.Errorf("CheckDigests() found %d missing, want 1", got)
+	}
+}
@@ -0,0 +1,76 @@
+package docker
+
+// Directives are comments directly above an instruction, e.g.,
+// # uptodate: ignore
+// # uptodate: filter=^[0-9]+\.04$
+// # uptodate: pin=digest
+// # uptodate: bump=minor
+// # uptodate: github-release spack/spack
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+// DirectivePrefix starts a comment with directives for the next instruction
+var DirectivePrefix = "uptodate:"
+
+// DirectiveFlags are directives without a value, set to true
+var DirectiveFlags = []string{"ignore"}
+
+// directiveKey is the key of a key=value directive, other words with = (e.g., >=4.2) are not
+var directiveKey = regexp.MustCompile(`^[a-z][a-z_-]*$`)
+
+// Directives reads the directives in comments directly above a command.
+// Words that aren't flags or key=value pairs are a source (and its
+// arguments) for an ARG, set as source and args.
+func (d *Dockerfile) Directives(cmd Command) map[string]string {
+	directives := map[string]string{}
+	if d.Raw == "" {
+		d.Raw = utils.ReadFile(d.Path)
+	}
+	lines := strings.Split(d.Raw, "\n")
+	for i := cmd.StartIndex() - 1; i >= 0 && i < len(lines); i-- {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if !strings.HasPrefix(line, DirectivePrefix) {
+			continue
+		}
+		words := []string{}
+		for _, field := range strings.Fields(strings.TrimPrefix(line, DirectivePrefix)) {
+			parts := strings.SplitN(field, "=", 2)
+			if len(parts) == 2 && !directiveKey.MatchString(parts[0]) {
+				parts = []string{field}
+			}
+			if len(parts) == 1 && !utils.IncludesString(field, DirectiveFlags) {
+				words = append(words, field)
+				continue
+			}
+			if _, ok := directives[parts[0]]; ok {
+				continue
+			}
+			if len(parts) == 1 {
+				directives[parts[0]] = "true"
+			} else {
+				directives[parts[0]] = parts[1]
+			}
+		}
+
+		// Other words name a source and its arguments, e.g., github-release spack/spack
+		if _, ok := directives["source"]; !ok && len(words) > 0 {
+			directives["source"] = words[0]
+			directives["args"] = strings.Join(words[1:], " ")
+		}
+	}
+	return directives
+}
+
+// isIgnored determines if directives ask to leave an instruction alone
+func isIgnored(directives map[string]string) bool {
+	return directives["ignore"] == "true"
+}
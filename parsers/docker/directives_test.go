@@ -0,0 +1,78 @@
+package docker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+func TestDirectives(t *testing.T) {
+	content := `# uptodate: filter=^[0-9]+\.04$
+FROM ubuntu:20.04
+
+# uptodate: bump=minor
+# A comment between directives
+#uptodate: ignore bump=major
+FROM python:3.10
+
+# uptodate: ignore
+RUN echo hello
+FROM alpine:3.14
+`
+	d, cleanup := parseDockerfile(t, content)
+	defer cleanup()
+	froms := d.Cmds["from"]
+	tests := []map[string]string{
+		{"filter": `^[0-9]+\.04$`},
+		{"ignore": "true", "bump": "major"},
+		{},
+	}
+	for i, want := range tests {
+		if got := d.Directives(froms[i]); !reflect.DeepEqual(got, want) {
+			t.Errorf("Directives(%s) = %v, want %v", froms[i].Original, got, want)
+		}
+	}
+}
+
+func TestIgnoredFroms(t *testing.T) {
+	content := `ARG BASE=ubuntu:18.04
+# uptodate: ignore
+FROM ubuntu:18.04
+# uptodate: ignore
+FROM ${BASE}
+FROM ubuntu:16.04
+`
+	d, cleanup := parseDockerfile(t, content)
+	defer cleanup()
+	d.ReplaceFroms("ubuntu", "20.04")
+	if len(d.Updates) != 1 || d.Updates[0].Token != "ubuntu:16.04" || d.Updates[0].Replacement != "ubuntu:20.04" {
+		t.Errorf("ReplaceFroms() = %+v, want only ubuntu:16.04 updated", d.Updates)
+	}
+}
+
+func TestFilterAndPinDirectives(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	server, _ := newTestRegistry([]string{"18.04", "20.04", "20.10", "21.04"})
+	defer server.Close()
+	name := strings.TrimPrefix(server.URL, "http://") + "/team/app"
+
+	d := Dockerfile{}
+	tests := []struct {
+		directives map[string]string
+		want       string
+	}{
+		{map[string]string{"filter": `^[0-9]+\.04$`}, name + ":21.04"},
+		{map[string]string{"filter": `^20[.]`, "bump": "major"}, name + ":20.10"},
+		{map[string]string{"filter": `^[0-9]+\.04$`, "pin": "digest"}, name + ":18.04"},
+		{map[string]string{}, name + ":18.04"},
+	}
+	for _, tt := range tests {
+		if got := d.bumpTag(name+":18.04", tt.directives); got != tt.want {
+			t.Errorf("bumpTag() with %v = %q, want %q", tt.directives, got, tt.want)
+		}
+	}
+}
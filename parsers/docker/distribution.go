@@ -4,10 +4,8 @@ package docker
 
 import (
 	"encoding/json"
-	"strings"
+	"fmt"
 	"time"
-
-	"github.com/vsoch/uptodate/utils"
 )
 
 type ImageConfig struct {
@@ -65,24 +63,62 @@ type ImageConfig struct {
 	} `json:"rootfs"`
 }
 
-// GetImageConfig of an existing container
+// GetImageConfig of an existing container, by digest if it has one or else by tag
 func GetImageConfig(container string) ImageConfig {
 
-	// Get tags for current container image
-	configUrl := "https://crane.ggcr.dev/config/" + container
-	response := utils.GetRequest(configUrl, map[string]string{})
-
+	// Get the manifest for the current container image
 	imageConf := ImageConfig{}
-	json.Unmarshal([]byte(response), &imageConf)
+	ref, err := ParseReference(container)
+	if err != nil {
+		fmt.Printf("Cannot parse %s: %s\n", container, err)
+		return imageConf
+	}
+	name := ref.Name
+	reference := ref.GetTag()
+	if ref.Digest != "" {
+		reference = ref.Digest
+	}
+	manifest, _, err := DefaultRegistry.GetManifest(name, reference)
+	if err != nil {
+		fmt.Printf("Cannot get manifest for %s: %s\n", container, err)
+		return imageConf
+	}
+
+	// An index points to a manifest per platform, we use the default platform
+	if manifest.IsIndex() {
+		descriptor, ok := selectPlatform(manifest, DefaultPlatform)
+		if !ok {
+			fmt.Printf("%s does not have a %s manifest.\n", container, DefaultPlatform)
+			return imageConf
+		}
+		manifest, _, err = DefaultRegistry.GetManifest(name, descriptor.Digest)
+		if err != nil {
+			fmt.Printf("Cannot get manifest for %s: %s\n", container, err)
+			return imageConf
+		}
+	}
+	response, err := DefaultRegistry.GetBlob(name, manifest.Config.Digest)
+	if err != nil {
+		fmt.Printf("Cannot get config for %s: %s\n", container, err)
+		return imageConf
+	}
+	json.Unmarshal(response, &imageConf)
 
 	// We don't care about the error - a missing config means we will build anyway
 	return imageConf
 }
 
-// Get image tags for a container
-func GetImageTags(container string) []string {
-	tagsUrl := "https://crane.ggcr.dev/ls/" + container
-	response := utils.GetRequest(tagsUrl, map[string]string{})
-	tags := strings.Split(response, "\n")
+// MaxTags is the default limit for tags listed for a container
+var MaxTags = 10000
+
+// Get image tags for a container, up to a maximum (zero uses the default)
+func GetImageTags(container string, maxTags int) []string {
+	if maxTags == 0 {
+		maxTags = MaxTags
+	}
+	tags, err := DefaultRegistry.GetTags(container, maxTags)
+	if err != nil {
+		fmt.Printf("Cannot list tags for %s: %s\n", container, err)
+	}
 	return tags
 }
@@ -6,25 +6,127 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
-	lookout "github.com/alecbcs/lookout/update"
+	"github.com/vsoch/uptodate/config"
 	"github.com/vsoch/uptodate/parsers"
-	"github.com/vsoch/uptodate/parsers/github"
-	"github.com/vsoch/uptodate/parsers/spack"
 )
 
 // GetVersions of existing container within user preferences
 func GetVersions(container string, filters []string, startAtVersion string, endAtVersion string,
-	skipVersions []string, includeVersions []string) []string {
+	skipVersions []string, includeVersions []string, maxTags int) []string {
 
 	// Get tags for current container image
-	tags := GetImageTags(container)
+	tags := GetImageTags(container, maxTags)
 	sort.Sort(sort.StringSlice(tags))
 	return parsers.GetVersions(tags, filters, startAtVersion, endAtVersion, skipVersions, includeVersions)
 }
 
+// LabelMaxTags is the default limit for tags whose config is read for a label
+var LabelMaxTags = 100
+
+// GetLabelVersions gets tags for a container, filtered and ordered by the version in an image
+// label (e.g., org.opencontainers.image.version) of each. The tags are returned, and when
+// several tags share a version (e.g., a date and a sha tag) the first in sorted order is kept.
+// Up to maxTags tags are listed, as for GetVersions. Reading a label needs the config of a tag,
+// so only the last labelMaxTags tags the registry lists (LabelMaxTags if zero) are read.
+func GetLabelVersions(container string, label string, filters []string, startAtVersion string, endAtVersion string,
+	skipVersions []string, includeVersions []string, maxTags int, labelMaxTags int) []string {
+
+	if labelMaxTags == 0 {
+		labelMaxTags = LabelMaxTags
+	}
+	tags := GetImageTags(container, maxTags)
+	if len(tags) > labelMaxTags {
+		skipped := tags[:len(tags)-labelMaxTags]
+		fmt.Printf("Reading labels of the last %d of %d tags of %s, skipping %s\n", labelMaxTags, len(tags), container, strings.Join(skipped, ", "))
+		tags = append([]string{}, tags[len(tags)-labelMaxTags:]...)
+	}
+	sort.Sort(sort.StringSlice(tags))
+
+	// Map each labeled version back to a tag
+	tagFor := map[string]string{}
+	labels := []string{}
+	for _, tag := range tags {
+		version := GetImageConfig(container + ":" + tag).Config.Labels[label]
+		if version == "" {
+			continue
+		}
+		if _, ok := tagFor[version]; ok {
+			continue
+		}
+		tagFor[version] = tag
+		labels = append(labels, version)
+	}
+	if len(labels) == 0 {
+		fmt.Printf("No tags of %s have a %s label.\n", container, label)
+	}
+
+	// Filtering and ordering is done on the versions, but we return tags
+	versions := []string{}
+	for _, version := range parsers.GetVersions(labels, filters, startAtVersion, endAtVersion, skipVersions, includeVersions) {
+		if tag, ok := tagFor[version]; ok {
+			versions = append(versions, tag)
+		}
+	}
+	return versions
+}
+
+// AgeMaxTags is the most tags whose creation time is looked up to filter versions by age
+var AgeMaxTags = 100
+
+// FilterVersionsByAge looks up when tags were created to filter versions by age. Versions
+// (already filtered otherwise) go from oldest to newest, so we look up from the newest and
+// stop at the first version old enough that older versions don't need a lookup
+func FilterVersionsByAge(container string, versions []string, newerThan string, minAge string) []string {
+	if newerThan == "" && minAge == "" {
+		return versions
+	}
+
+	// Ages are validated when the config loads
+	var maxAge, minimum time.Duration
+	if newerThan != "" {
+		maxAge, _ = config.ParseAge(newerThan)
+	}
+	if minAge != "" {
+		minimum, _ = config.ParseAge(minAge)
+	}
+
+	// Older versions kept without a lookup, and the first version that was looked up
+	older := []string{}
+	start := 0
+	created := map[string]time.Time{}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if len(created) == AgeMaxTags {
+			fmt.Printf("Looked up the age of the newest %d versions of %s, keeping the %d older ones.\n", AgeMaxTags, container, i+1)
+			older = append(older, versions[:i+1]...)
+			start = i + 1
+			break
+		}
+		when := GetImageConfig(container + ":" + versions[i]).Created
+		created[versions[i]] = when
+		if when.IsZero() {
+			continue
+		}
+
+		// Too old means older versions are too, and old enough means older versions are too
+		age := time.Since(when)
+		if maxAge > 0 && age > maxAge {
+			start = i
+			break
+		}
+		if maxAge == 0 && age >= minimum {
+			older = append(older, versions[:i]...)
+			start = i
+			break
+		}
+	}
+	return append(older, parsers.FilterByAge(versions[start:], created, newerThan, minAge)...)
+}
+
 // UpdateFrom updates a single From, and returns an Update
-func UpdateFrom(fromValue []string) parsers.Update {
+// The digest policy decides between an index or a platform digest
+func UpdateFrom(fromValue []string, policy DigestPolicy) parsers.Update {
 
 	// We will return an update, empty if none
 	update := parsers.Update{}
@@ -41,41 +143,33 @@ func UpdateFrom(fromValue []string) parsers.Update {
 		return update
 	}
 
-	// We want to keep track of having a hash and/or tag
-	hasHash := false
-	hasTag := false
-
-	// First remove any digest from the container
-	if strings.Contains(container, "@") {
-		parts := strings.SplitN(container, "@", 2)
-		container = parts[0]
-		hasHash = true
+	ref, err := ParseReference(container)
+	if err != nil {
+		fmt.Printf("Cannot parse %s: %s\n", container, err)
+		return update
 	}
+	if ref.Tag == "" {
 
-	// Now extract any tag from the container
-	tag := "latest"
-	if strings.Contains(container, ":") {
-		parts := strings.SplitN(container, ":", 2)
-		container = parts[0]
-		tag = parts[1]
-		hasTag = true
-	} else {
+		// If it has a hash but no tag, we can't look up a newer digest
+		if ref.Digest != "" {
+			fmt.Printf("Cannot parse %s, has a hash but no tag, cannot be looked up.\n", container)
+			return update
+		}
 		fmt.Printf("No tag specified for %s, will default to latest.\n", container)
 	}
 
-	// If it has a hash but no digest, we can't correctly parse
-	if hasHash && !hasTag {
-		fmt.Printf("Cannot parse %s, has a hash but no tag, cannot be looked up.\n", container)
-		return update
-	}
-
 	// Get the updated container hash for the tag
-	url := container + ":" + tag
-	updated := getUpdatedContainer(url)
+	url := ref.Name + ":" + ref.GetTag()
+	updated := getUpdatedContainer(url, policy)
 
 	// Do we have an update?
 	if updated != "" {
 
+		// A mirror can ask to write the reference with its prefix
+		if written := mirrorRewrite(DefaultRegistry.Mirrors, ref); written != ref.Name {
+			updated = written + strings.TrimPrefix(updated, ref.Name)
+		}
+
 		// Add original content back
 		for _, extra := range fromValue[1:] {
 			updated += " " + extra
@@ -83,11 +177,7 @@ func UpdateFrom(fromValue []string) parsers.Update {
 
 		// If the updated version is different from the original, update
 		if updated != original {
-
-			// TODO I've never seen a multi-line FROM, but this will need
-			// adjustment if one exists to replace a range of lines
 			update = parsers.Update{Original: original, Updated: updated}
-
 		} else {
 			fmt.Println("No difference between:", updated, original)
 		}
@@ -95,21 +185,27 @@ func UpdateFrom(fromValue []string) parsers.Update {
 	return update
 }
 
-// getUpdatedContainer uses lookout to get an updated sha
+// getUpdatedContainer asks the registry for the current digest of a tag
 // No update returns an empty string
-func getUpdatedContainer(url string) string {
-	out, found := lookout.CheckUpdate("docker://" + url)
+func getUpdatedContainer(url string, policy DigestPolicy) string {
+	ref, err := ParseReference(url)
+	var digest string
+	if err == nil {
+		digest, err = policy.GetDigest(ref.Name, ref.GetTag())
+	}
 
 	var updated string
-	if found {
-		result := *out
-		updated = url + "@" + result.Name
+	if err == nil {
+		updated = url + "@" + digest
+	} else {
+		fmt.Printf("Cannot get digest for %s: %s\n", url, err)
 	}
 	return updated
 }
 
-// UpdateArg updates a build arg that is a known pattern
-func UpdateArg(values []string) parsers.Update {
+// UpdateArg updates a build arg from a source named in directives, or else a known
+// pattern of the name. A filter directive (regular expression) limits the versions it can move to
+func UpdateArg(values []string, directives map[string]string) parsers.Update {
 
 	// We will return an update, empty if none
 	update := parsers.Update{}
@@ -134,13 +230,18 @@ func UpdateArg(values []string) parsers.Update {
 		return update
 	}
 
-	// Determine if it matches spack or Github
-	if strings.HasPrefix(name, "uptodate_spack") {
-		return spack.UpdateBuildArg(values)
-	} else if strings.HasPrefix(name, "uptodate_github_release") {
-		return github.UpdateReleaseBuildArg(values)
-	} else if strings.HasPrefix(name, "uptodate_github_commit") {
-		return github.UpdateCommitBuildArg(values)
+	// A source from a directive comes first, then updaters matching the name
+	filter := directives["filter"]
+	if source, ok := directives["source"]; ok {
+		updater, ok := parsers.GetUpdater(source)
+		if !ok {
+			fmt.Printf("Unknown source %s for %s, known sources are %s\n", source, arg, strings.Join(parsers.Updaters(), ", "))
+			return update
+		}
+		return parsers.UpdateBuildArg(values, updater, strings.Fields(directives["args"]), filter)
+	}
+	if updater, ok := parsers.MatchUpdater(name); ok {
+		return parsers.UpdateBuildArg(values, updater, []string{}, filter)
 	}
 	return update
 }
@@ -0,0 +1,115 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+func TestFilterVersionsByAge(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	// Each tag has a config with the days since it was created
+	days := map[string]int{"1": 600, "2": 500, "3": 400, "4": 300, "5": 2, "6": 1}
+	lookups := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/app/manifests/") {
+			tag := strings.TrimPrefix(r.URL.Path, "/v2/app/manifests/")
+			w.Header().Set("Content-Type", MediaTypeOCIManifest)
+			json.NewEncoder(w).Encode(Manifest{SchemaVersion: 2, MediaType: MediaTypeOCIManifest,
+				Config: Descriptor{Digest: "sha256:" + strings.Repeat(tag, 64)}})
+			return
+		}
+		tag := string(r.URL.Path[len(r.URL.Path)-1])
+		lookups++
+		created := time.Now().Add(-time.Duration(days[tag]) * 24 * time.Hour).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `{"created": "%s"}`, created)
+	}))
+	defer server.Close()
+	container := strings.TrimPrefix(server.URL, "http://") + "/app"
+	versions := []string{"1", "2", "3", "4", "5", "6"}
+
+	tests := []struct {
+		name      string
+		newerThan string
+		minAge    string
+		maxTags   int
+		want      []string
+		lookups   int
+	}{
+		{"newer than", "365d", "", 100, []string{"4", "5", "6"}, 4},
+		{"min age", "", "3d", 100, []string{"1", "2", "3", "4"}, 3},
+		{"both", "365d", "3d", 100, []string{"4"}, 4},
+		{"capped", "365d", "", 2, []string{"1", "2", "3", "4", "5", "6"}, 2},
+	}
+	maxTags := AgeMaxTags
+	defer func() { AgeMaxTags = maxTags }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookups = 0
+			AgeMaxTags = tt.maxTags
+			got := FilterVersionsByAge(container, versions, tt.newerThan, tt.minAge)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterVersionsByAge() = %v, want %v", got, tt.want)
+			}
+			if lookups != tt.lookups {
+				t.Errorf("FilterVersionsByAge() read %d configs, want %d", lookups, tt.lookups)
+			}
+		})
+	}
+	if !reflect.DeepEqual(versions, []string{"1", "2", "3", "4", "5", "6"}) {
+		t.Errorf("FilterVersionsByAge() changed the versions it was given: %v", versions)
+	}
+}
+
+func TestGetLabelVersions(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	// Tags are listed in the order the registry gives, each labeled with a version
+	tags := []string{"2024-01-01", "sha-aaa", "2024-02-01", "sha-bbb", "unlabeled"}
+	labels := map[string]string{"2024-01-01": "1.0", "sha-aaa": "1.0", "2024-02-01": "1.1", "sha-bbb": "1.1"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/app/tags/list":
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "app", "tags": tags})
+		case strings.HasPrefix(r.URL.Path, "/v2/app/manifests/"):
+			tag := strings.TrimPrefix(r.URL.Path, "/v2/app/manifests/")
+			w.Header().Set("Content-Type", MediaTypeOCIManifest)
+			json.NewEncoder(w).Encode(Manifest{SchemaVersion: 2, MediaType: MediaTypeOCIManifest,
+				Config: Descriptor{Digest: "sha256:" + tag}})
+		default:
+			tag := strings.TrimPrefix(r.URL.Path, "/v2/app/blobs/sha256:")
+			fmt.Fprintf(w, `{"config": {"Labels": {"org.opencontainers.image.version": "%s"}}}`, labels[tag])
+		}
+	}))
+	defer server.Close()
+	container := strings.TrimPrefix(server.URL, "http://") + "/app"
+
+	tests := []struct {
+		name         string
+		maxTags      int
+		labelMaxTags int
+		want         []string
+	}{
+		{"all tags", 0, 0, []string{"2024-01-01", "2024-02-01"}},
+		{"labels of the last listed", 0, 3, []string{"2024-02-01"}},
+		{"listing limit", 2, 0, []string{"2024-01-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetLabelVersions(container, "org.opencontainers.image.version", []string{}, "", "", []string{}, []string{}, tt.maxTags, tt.labelMaxTags)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetLabelVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,10 +11,17 @@ import (
 	"strings"
 
 	df "github.com/asottile/dockerfile"
+	"github.com/vsoch/uptodate/config"
 	"github.com/vsoch/uptodate/parsers"
 	"github.com/vsoch/uptodate/parsers/git"
 	"github.com/vsoch/uptodate/utils"
 	"path/filepath"
+
+	// Sources register their build arg updaters when imported
+	_ "github.com/vsoch/uptodate/parsers/github"
+	_ "github.com/vsoch/uptodate/parsers/goproxy"
+	_ "github.com/vsoch/uptodate/parsers/npm"
+	_ "github.com/vsoch/uptodate/parsers/pypi"
 )
 
 // Command extends a command to perform custom parsing functions
@@ -47,6 +54,14 @@ type Dockerfile struct {
 	Raw     string
 	Cmds    map[string][]Command // Lookup by command type for quicker parsing
 	Updates []parsers.Update
+	Policy  DigestPolicy // Index or platform digests for FROM
+
+	// When set, new digests without a valid signature are reported as Unsigned
+	Verifier *SignatureVerifier
+	Unsigned []parsers.Update
+
+	// Settings for images from the repository config (e.g., bump policies)
+	Images []config.Image
 }
 
 // Determine if a Dockerfile contains build args
@@ -127,9 +142,9 @@ func (d *Dockerfile) AddCommands(cmds []df.Command) {
 func (d *Dockerfile) AddCommand(cmd df.Command) {
 	extendedCmd := Command(cmd)
 
-	// We only care about FROM statements, but maybe others in the future
+	// We care about FROM and ARG statements, and images in COPY and RUN flags
 	commandType := strings.ToLower(cmd.Cmd)
-	if utils.IncludesString(commandType, []string{"from", "arg"}) {
+	if utils.IncludesString(commandType, []string{"from", "arg", "copy", "run"}) {
 
 		// Add to lookup, checking if key already exists
 		if _, ok := d.Cmds[commandType]; ok {
@@ -146,33 +161,112 @@ func (d *Dockerfile) UpdateFroms() {
 	// Prepare a set of updates
 	d.Updates = []parsers.Update{}
 
+	// ARG defaults a FROM can be declared through, and those we've updated
+	args := d.GlobalArgs()
+	seen := map[string]bool{}
+
+	// Earlier stages are not images we can look up
+	stages := d.Stages()
+
 	// Loop through FROMs and update!
-	for _, from := range d.Cmds["from"] {
+	for i, from := range d.Cmds["from"] {
+		if isStageReference(stages, i) || strings.ToLower(from.Value[0]) == "scratch" {
+			continue
+		}
+
+		// Directives can ask us to leave an instruction alone
+		directives := d.Directives(from)
+		if isIgnored(directives) {
+			continue
+		}
+
+		// A FROM declared through an ARG updates the default instead
+		if arg, ok := resolveFrom(from, args); ok {
+			for key, value := range d.Directives(arg.Command) {
+				if _, ok := directives[key]; !ok {
+					directives[key] = value
+				}
+			}
+			if !isIgnored(directives) && !seen[arg.Name] {
+				seen[arg.Name] = true
+				d.updateFromArg(from, arg, directives)
+			}
+			continue
+		}
+
+		// With a bump policy we might move to a newer tag first
+		fromValue := append([]string{d.bumpTag(from.Value[0], directives)}, from.Value[1:]...)
 
 		// An "empty" update will be returned if nothing to do
-		newUpdate := UpdateFrom(from.Value)
+		newUpdate := UpdateFrom(fromValue, d.Policy.ForFlags(from.Flags, directives))
 		if !reflect.DeepEqual(newUpdate, parsers.Update{}) {
-			newUpdate.Updated = "FROM " + newUpdate.Updated
-			newUpdate.Original = from.Original
-			newUpdate.LineNo = from.StartIndex()
-			d.Updates = append(d.Updates, newUpdate)
+			verified := d.verifyUpdate(newUpdate)
+			newUpdate = tokenUpdate(from, from.Value[0], strings.Fields(newUpdate.Updated)[0])
+			if verified {
+				d.Updates = append(d.Updates, newUpdate)
+			} else {
+				d.Unsigned = append(d.Unsigned, newUpdate)
+			}
 		}
 
 	}
 }
 
-// UpdateArgs, updates build args that match a known pattern
+// updateFromArg updates the default of an ARG that a FROM is declared through
+func (d *Dockerfile) updateFromArg(from Command, arg GlobalArg, directives map[string]string) {
+	newUpdate := UpdateFrom([]string{d.bumpTag(arg.Value, directives)}, d.Policy.ForFlags(from.Flags, directives))
+	if reflect.DeepEqual(newUpdate, parsers.Update{}) {
+		return
+	}
+	if !d.verifyUpdate(newUpdate) {
+		d.Unsigned = append(d.Unsigned, arg.Update(newUpdate.Updated))
+		return
+	}
+	d.Updates = append(d.Updates, arg.Update(newUpdate.Updated))
+}
+
+// verifyUpdate checks the signature of the new digest of an update, if we have a verifier
+func (d *Dockerfile) verifyUpdate(update parsers.Update) bool {
+	if d.Verifier == nil {
+		return true
+	}
+	ref, err := ParseReference(strings.Fields(update.Updated)[0])
+	if err != nil || ref.Digest == "" {
+		fmt.Printf("Not updating to %s, it does not have a digest to verify.\n", update.Updated)
+		return false
+	}
+	if err := d.Verifier.Verify(ref.Name, ref.Digest); err != nil {
+		fmt.Printf("Not updating to %s: %s\n", update.Updated, err)
+		return false
+	}
+	return true
+}
+
+// UpdateArgs, updates build args with a source in a directive, or that match a known pattern
+// # uptodate: github-release spack/spack (source directive example)
 // ARG uptodate_spack_ace=6.5.12  (spack example)
 // ARG uptodate_github_spack__spack=v0.16.1 (github release example)
 func (d *Dockerfile) UpdateArgs() {
 
 	// d.Updates should already be created from Update Froms
+	installs := d.GoInstalls()
 	for _, buildarg := range d.Cmds["arg"] {
-		newUpdate := UpdateArg(buildarg.Value)
+		directives := d.Directives(buildarg)
+		if isIgnored(directives) {
+			continue
+		}
+
+		// A go source without a module uses the one the arg is go installed with
+		name := strings.SplitN(buildarg.Value[0], "=", 2)[0]
+		if pkg, ok := installs[name]; ok && directives["source"] == "go" && directives["args"] == "" {
+			directives["args"] = pkg
+		}
+
+		// A quoted default keeps its quotes
+		values, quote := unquoteArg(buildarg.Value)
+		newUpdate := UpdateArg(values, directives)
 		if !reflect.DeepEqual(newUpdate, parsers.Update{}) {
-			newUpdate.Updated = "ARG " + newUpdate.Updated
-			newUpdate.Original = buildarg.Original
-			newUpdate.LineNo = buildarg.StartIndex()
+			newUpdate = tokenUpdate(buildarg, buildarg.Value[0], quoteArg(strings.Fields(newUpdate.Updated)[0], quote))
 			d.Updates = append(d.Updates, newUpdate)
 		}
 	}
@@ -185,44 +279,97 @@ func (d *Dockerfile) ReplaceFroms(name string, tag string) {
 	// Prepare a set of updates
 	d.Updates = []parsers.Update{}
 
+	// The name we are looking for, normalized to compare
+	wanted, err := ParseReference(name)
+	if err != nil {
+		log.Printf("Cannot parse %s: %s\n", name, err)
+		return
+	}
+
 	// Loop through FROMs and update! See UpdateFroms for comments
-	for _, from := range d.Cmds["from"] {
+	args := d.GlobalArgs()
+	stages := d.Stages()
+	for i, from := range d.Cmds["from"] {
+		if isStageReference(stages, i) || isIgnored(d.Directives(from)) {
+			continue
+		}
 
 		container := from.Value[0]
 
+		// A FROM declared through an ARG replaces the default
+		if arg, ok := resolveFrom(from, args); ok {
+			ref, err := ParseReference(arg.Value)
+			if err == nil && ref.SameRepository(wanted) && !isIgnored(d.Directives(arg.Command)) {
+				d.Updates = append(d.Updates, arg.Update(ref.Name+":"+tag))
+			}
+			continue
+		}
+
 		// We can't reliably replace a variable
 		isVariable := strings.Contains(container, "$")
 		if isVariable {
 			continue
 		}
 
-		// Just get rid of hashes and tags so we have base container name
-		if strings.Contains(container, "@") {
-			parts := strings.SplitN(container, "@", 2)
-			container = parts[0]
+		// Compare the repositories, so ubuntu matches docker.io/library/ubuntu
+		ref, err := ParseReference(container)
+		if err != nil {
+			continue
 		}
-
-		if strings.Contains(container, ":") {
-			parts := strings.SplitN(container, ":", 2)
-			container = parts[0]
+		if ref.SameRepository(wanted) {
+			update := tokenUpdate(from, container, ref.Name+":"+tag)
+			d.Updates = append(d.Updates, update)
 		}
 
-		// Clean up white spaces, and check if we have a match
-		container = strings.Trim(container, " ")
-		if container == name {
-
-			updated := container + ":" + tag
+	}
+}
 
-			// Add original content back
-			for _, extra := range from.Value[1:] {
-				updated += " " + extra
-			}
+// tokenUpdate returns an update that replaces one token of a command in place
+func tokenUpdate(cmd Command, token string, replacement string) parsers.Update {
+	updated, _ := replaceToken(cmd.Original, token, replacement)
+	return parsers.Update{
+		Type:        strings.ToLower(cmd.Cmd),
+		Original:    cmd.Original,
+		Updated:     updated,
+		LineNo:      cmd.StartIndex(),
+		EndLineNo:   cmd.EndIndex(),
+		Token:       token,
+		Replacement: replacement,
+	}
+}
 
-			update := parsers.Update{Original: from.Original, Updated: updated, LineNo: from.StartIndex()}
-			d.Updates = append(d.Updates, update)
+// replaceToken replaces the first whitespace delimited occurrence of a token
+func replaceToken(text string, token string, replacement string) (string, bool) {
+	offset := 0
+	for {
+		index := strings.Index(text[offset:], token)
+		if index < 0 {
+			return text, false
+		}
+		start := offset + index
+		end := start + len(token)
+		before := start == 0 || strings.ContainsAny(text[start-1:start], " \t")
+		after := end == len(text) || strings.ContainsAny(text[end:end+1], " \t\r\\")
+		if before && after {
+			return text[:start] + replacement + text[end:], true
 		}
+		offset = start + 1
+	}
+}
 
+// replaceTokenInLines replaces the token of an update in the lines of its instruction,
+// skipping comment lines inside a multi-line instruction
+func replaceTokenInLines(lines []string, update parsers.Update) bool {
+	for i := update.LineNo; i <= update.EndLineNo && i < len(lines); i++ {
+		if i > update.LineNo && strings.HasPrefix(strings.TrimSpace(lines[i]), "#") {
+			continue
+		}
+		if replaced, ok := replaceToken(lines[i], update.Token, update.Replacement); ok {
+			lines[i] = replaced
+			return true
+		}
 	}
+	return false
 }
 
 // Write writes a new Dockerfile
@@ -234,12 +381,18 @@ func (d *Dockerfile) Write() {
 	// Split into original lines
 	lines := strings.Split(raw, "\n")
 
-	// For each Update, replace exact line with new version
+	// For each Update, replace the token in place, or the exact line with new version
 	for _, update := range d.Updates {
 		fmt.Printf("Updating %s to %s\n", update.Original, update.Updated)
+		if update.Token == "" {
+			lines[update.LineNo] = update.Updated
+			continue
+		}
 
 		// This ensures we keep the tag preserved for future checks, but change the file so it rebuilds
-		lines[update.LineNo] = update.Updated
+		if !replaceTokenInLines(lines, update) {
+			fmt.Printf("Cannot find %s in %s, not updating.\n", update.Token, d.Path)
+		}
 	}
 	content := strings.Join(lines, "\n")
 	utils.WriteFile(d.Path, content)
@@ -248,6 +401,9 @@ func (d *Dockerfile) Write() {
 // DockerfileParser holds one or more Dockerfile
 type DockerfileParser struct {
 	Dockerfiles []Dockerfile
+	Policy      DigestPolicy
+	Verifier    *SignatureVerifier
+	Images      []config.Image
 }
 
 // AddDockerfile adds a Dockerfile to the Parser
@@ -278,9 +434,11 @@ func (d *Dockerfile) ParseCommands() {
 func (s *DockerfileParser) AddDockerfile(root string, path string) {
 
 	// Create a new Dockerfile entry
-	dockerfile := Dockerfile{Path: path, Root: root}
+	dockerfile := Dockerfile{Path: path, Root: root, Policy: s.Policy, Verifier: s.Verifier, Images: s.Images}
 	dockerfile.ParseCommands()
 	dockerfile.UpdateFroms()
+	dockerfile.UpdateSyntax()
+	dockerfile.UpdateFlagImages()
 	dockerfile.UpdateArgs()
 	s.Dockerfiles = append(s.Dockerfiles, dockerfile)
 }
@@ -342,6 +500,31 @@ func (s *DockerfileParser) Parse(path string, dryrun bool, changesOnly bool, bra
 		fmt.Printf("    Modified: %d\n", count)
 	}
 
+	// Count updates by type, e.g., a FROM or the syntax directive
+	labels := map[string]string{"from": "FROM", "arg": "ARG", "copy": "COPY", "run": "RUN", "syntax": "Syntax"}
+	for _, updateType := range []string{"from", "arg", "copy", "run", "syntax"} {
+		typeCount := 0
+		for _, dockerfile := range s.Dockerfiles {
+			for _, update := range dockerfile.Updates {
+				if update.Type == updateType {
+					typeCount += 1
+				}
+			}
+		}
+		if typeCount > 0 {
+			fmt.Printf("%12s: %d\n", labels[updateType], typeCount)
+		}
+	}
+
+	// Updates we didn't write because they aren't signed
+	unsigned := []parsers.Update{}
+	for _, dockerfile := range s.Dockerfiles {
+		for _, update := range dockerfile.Unsigned {
+			unsigned = append(unsigned, update)
+			fmt.Printf("    Unsigned: %s (%s)\n", update.Updated, dockerfile.Path)
+		}
+	}
+
 	// If we are running in a GitHub Action, set the outputs
 	if utils.IsGitHubAction() {
 		outJson, _ := json.Marshal(results)
@@ -353,6 +536,8 @@ func (s *DockerfileParser) Parse(path string, dryrun bool, changesOnly bool, bra
 		}
 		utils.WriteGitHubOutput("dockerfile_matrix", output)
 		utils.WriteGitHubOutput("dockerfile_matrix_empty", strconv.FormatBool(isEmpty))
+		unsignedJson, _ := json.Marshal(unsigned)
+		utils.WriteGitHubOutput("dockerfile_unsigned", string(unsignedJson))
 
 	}
 	return nil
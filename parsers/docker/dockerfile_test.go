@@ -0,0 +1,115 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vsoch/uptodate/parsers"
+)
+
+func TestReplaceToken(t *testing.T) {
+	tests := []struct {
+		text        string
+		token       string
+		replacement string
+		want        string
+		replaced    bool
+	}{
+		{"FROM ubuntu:20.04", "ubuntu:20.04", "ubuntu:22.04", "FROM ubuntu:22.04", true},
+		{"FROM --platform=linux/amd64 ubuntu AS base", "ubuntu", "ubuntu@sha256:abc", "FROM --platform=linux/amd64 ubuntu@sha256:abc AS base", true},
+		{"FROM\tubuntu\t# comment", "ubuntu", "ubuntu:22.04", "FROM\tubuntu:22.04\t# comment", true},
+		{"FROM ubuntu-base ubuntu", "ubuntu", "ubuntu:22.04", "FROM ubuntu-base ubuntu:22.04", true},
+		{"ARG VERSION=1.0 \\", "VERSION=1.0", "VERSION=2.0", "ARG VERSION=2.0 \\", true},
+		{"ARG VERSION=1.0\r", "VERSION=1.0", "VERSION=2.0", "ARG VERSION=2.0\r", true},
+		{`ARG BASE="ubuntu:20.04" OTHER=1`, `BASE="ubuntu:20.04"`, `BASE="ubuntu:22.04"`, `ARG BASE="ubuntu:22.04" OTHER=1`, true},
+		{"FROM myubuntu:20.04", "ubuntu:20.04", "ubuntu:22.04", "FROM myubuntu:20.04", false},
+		{"FROM ubuntu:20.04-slim", "ubuntu:20.04", "ubuntu:22.04", "FROM ubuntu:20.04-slim", false},
+	}
+	for _, tt := range tests {
+		got, replaced := replaceToken(tt.text, tt.token, tt.replacement)
+		if got != tt.want || replaced != tt.replaced {
+			t.Errorf("replaceToken(%q, %q, %q) = %q, %v, want %q, %v", tt.text, tt.token, tt.replacement, got, replaced, tt.want, tt.replaced)
+		}
+	}
+}
+
+func TestQuotedArg(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   []string
+		quote  string
+	}{
+		{[]string{`VERSION="1.0"`}, []string{"VERSION=1.0"}, `"`},
+		{[]string{"VERSION='1.0'", "OTHER=1"}, []string{"VERSION=1.0", "OTHER=1"}, "'"},
+		{[]string{"VERSION=1.0"}, []string{"VERSION=1.0"}, ""},
+		{[]string{`VERSION="`}, []string{`VERSION="`}, ""},
+		{[]string{"VERSION"}, []string{"VERSION"}, ""},
+	}
+	for _, tt := range tests {
+		values, quote := unquoteArg(tt.values)
+		if !reflect.DeepEqual(values, tt.want) || quote != tt.quote {
+			t.Errorf("unquoteArg(%q) = %q, %q, want %q, %q", tt.values, values, quote, tt.want, tt.quote)
+		}
+
+		// The updated default gets the same quotes back
+		if got := quoteArg(values[0], quote); got != tt.values[0] {
+			t.Errorf("quoteArg(%q, %q) = %q, want %q", values[0], quote, got, tt.values[0])
+		}
+	}
+}
+
+func TestWriteReplacesTokens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Dockerfile")
+	content := "FROM ubuntu:20.04 AS base\nRUN apt-get update && \\\n    # ubuntu:20.04 in a comment\n    echo ubuntu:20.04\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := Dockerfile{Path: path, Updates: []parsers.Update{
+		{Original: "FROM ubuntu:20.04 AS base", Updated: "FROM ubuntu:22.04 AS base", LineNo: 0, EndLineNo: 0, Token: "ubuntu:20.04", Replacement: "ubuntu:22.04"},
+		{Original: "RUN ...", Updated: "RUN ...", LineNo: 1, EndLineNo: 3, Token: "ubuntu:20.04", Replacement: "ubuntu:22.04"},
+	}}
+	d.Write()
+
+	written, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "FROM ubuntu:22.04 AS base\nRUN apt-get update && \\\n    # ubuntu:20.04 in a comment\n    echo ubuntu:22.04\n"
+	if string(written) != want {
+		t.Errorf("Write() wrote %q, want %q", written, want)
+	}
+}
+
+// fixedUpdater always has version 2.0
+type fixedUpdater struct{}
+
+func (u fixedUpdater) Name() string { return "fixed" }
+
+func (u fixedUpdater) Match(name string) bool { return false }
+
+func (u fixedUpdater) Latest(name string, value string, args []string, filter string) (string, error) {
+	return "2.0", nil
+}
+
+func TestUpdateArgsFromSource(t *testing.T) {
+	parsers.RegisterUpdater(fixedUpdater{})
+	content := "# uptodate: source=fixed\nARG VERSION=\"1.0\"\n# uptodate: source=missing\nARG OTHER=1.0\nARG PLAIN=1.0\n"
+	d, cleanup := parseDockerfile(t, content)
+	defer cleanup()
+	d.UpdateArgs()
+
+	// Only the arg with a known source is updated, and it keeps its quotes
+	if len(d.Updates) != 1 || d.Updates[0].Token != `VERSION="1.0"` || d.Updates[0].Replacement != `VERSION="2.0"` {
+		t.Errorf("UpdateArgs() = %+v, want VERSION=\"2.0\"", d.Updates)
+	}
+}
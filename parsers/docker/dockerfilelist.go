@@ -15,6 +15,7 @@ import (
 // DockerfileListParser holds one or more Dockerfile
 type DockerfileListParser struct {
 	Dockerfiles []Dockerfile
+	ShowStages  bool // Print the build stages of each Dockerfile
 }
 
 // AddDockerfile adds a Dockerfile to the Parser
@@ -61,8 +62,9 @@ func (s *DockerfileListParser) Parse(providedPaths []string, includeEmptyArgs bo
 		return nil
 	}
 
-	// Keep track of updated count and set of results
+	// Keep track of updated count and set of results, and stages by path
 	results := []parsers.Result{}
+	stages := map[string][]Stage{}
 
 	// Print each dockerfile to the console
 	for _, dockerfile := range s.Dockerfiles {
@@ -72,6 +74,13 @@ func (s *DockerfileListParser) Parse(providedPaths []string, includeEmptyArgs bo
 		results = append(results, result)
 		fmt.Println(dockerfile.Path)
 
+		stages[dockerfile.Path] = dockerfile.Stages()
+		if s.ShowStages {
+			for _, stage := range stages[dockerfile.Path] {
+				fmt.Println("    " + stage.Describe())
+			}
+		}
+
 	}
 
 	// If we are running in a GitHub Action, set the outputs
@@ -85,6 +94,8 @@ func (s *DockerfileListParser) Parse(providedPaths []string, includeEmptyArgs bo
 		}
 		utils.WriteGitHubOutput("dockerfilelist_matrix", output)
 		utils.WriteGitHubOutput("dockerfilelist_matrix_empty", strconv.FormatBool(isEmpty))
+		stagesJson, _ := json.Marshal(stages)
+		utils.WriteGitHubOutput("dockerfilelist_stages", string(stagesJson))
 	}
 	return nil
 }
@@ -0,0 +1,94 @@
+package docker
+
+// Images referenced in flags, e.g., COPY --from=ghcr.io/org/tools:1.2
+// or RUN --mount=type=bind,from=image:tag,target=/x
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vsoch/uptodate/parsers"
+)
+
+// FlagImage is an image referenced in a flag of a COPY or RUN instruction
+type FlagImage struct {
+	Command Command
+	Flag    string // The flag as written
+	Image   string
+}
+
+// Replace returns the flag as written with a new image
+func (f *FlagImage) Replace(image string) string {
+	if strings.HasPrefix(f.Flag, "--from=") {
+		return "--from=" + image
+	}
+	options := strings.Split(strings.TrimPrefix(f.Flag, "--mount="), ",")
+	for i, option := range options {
+		if strings.HasPrefix(option, "from=") {
+			options[i] = "from=" + image
+		}
+	}
+	return "--mount=" + strings.Join(options, ",")
+}
+
+// flagImage returns the image named by a COPY or RUN flag, if it has one
+func flagImage(command string, flag string) string {
+	if command == "copy" && strings.HasPrefix(flag, "--from=") {
+		return strings.TrimPrefix(flag, "--from=")
+	}
+	if command == "run" && strings.HasPrefix(flag, "--mount=") {
+		for _, option := range strings.Split(strings.TrimPrefix(flag, "--mount="), ",") {
+			if strings.HasPrefix(option, "from=") {
+				return strings.TrimPrefix(option, "from=")
+			}
+		}
+	}
+	return ""
+}
+
+// FlagImages finds images in COPY --from and RUN --mount flags. Stages
+// (by name or index) and variables are not images we can update.
+func (d *Dockerfile) FlagImages() []FlagImage {
+	images := []FlagImage{}
+	stages := d.Stages()
+	for _, command := range []string{"copy", "run"} {
+		for _, cmd := range d.Cmds[command] {
+			for _, flag := range cmd.Flags {
+				image := flagImage(command, flag)
+				if image == "" || strings.Contains(image, "$") {
+					continue
+				}
+				if _, err := strconv.Atoi(image); err == nil {
+					continue
+				}
+				if _, ok := findStage(stages, image); ok {
+					continue
+				}
+				images = append(images, FlagImage{Command: cmd, Flag: flag, Image: image})
+			}
+		}
+	}
+	return images
+}
+
+// UpdateFlagImages updates images in flags as we would a FROM
+func (d *Dockerfile) UpdateFlagImages() {
+	for _, image := range d.FlagImages() {
+		directives := d.Directives(image.Command)
+		if isIgnored(directives) {
+			continue
+		}
+		newUpdate := UpdateFrom([]string{d.bumpTag(image.Image, directives)}, d.Policy.ForFlags([]string{}, directives))
+		if reflect.DeepEqual(newUpdate, parsers.Update{}) {
+			continue
+		}
+		verified := d.verifyUpdate(newUpdate)
+		newUpdate = tokenUpdate(image.Command, image.Flag, image.Replace(newUpdate.Updated))
+		if verified {
+			d.Updates = append(d.Updates, newUpdate)
+		} else {
+			d.Unsigned = append(d.Unsigned, newUpdate)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestFlagImages(t *testing.T) {
+	content := `FROM golang:1.17 AS build
+FROM ubuntu:20.04
+COPY --from=build /go/bin/app /app
+COPY --from=0 /go/bin/app /app
+COPY --from=${TOOLS} /tools /tools
+COPY --from=ghcr.io/org/tools:1.2 /tools /tools
+COPY --chown=1000 app.py /app.py
+RUN --mount=type=bind,from=python:3.10,source=/usr/local,target=/py ls /py
+RUN --mount=type=cache,target=/root/.cache pip install app
+`
+	d, cleanup := parseDockerfile(t, content)
+	defer cleanup()
+	images := d.FlagImages()
+	want := []string{"ghcr.io/org/tools:1.2", "python:3.10"}
+	if len(images) != len(want) {
+		t.Fatalf("FlagImages() = %+v, want %v", images, want)
+	}
+	for i, image := range images {
+		if image.Image != want[i] {
+			t.Errorf("FlagImages()[%d] = %s, want %s", i, image.Image, want[i])
+		}
+	}
+}
+
+func TestFlagImageReplace(t *testing.T) {
+	tests := []struct {
+		flag  string
+		image string
+		want  string
+	}{
+		{"--from=ghcr.io/org/tools:1.2", "ghcr.io/org/tools:1.3", "--from=ghcr.io/org/tools:1.3"},
+		{"--mount=type=bind,from=python:3.10,target=/py", "python:3.11", "--mount=type=bind,from=python:3.11,target=/py"},
+	}
+	for _, tt := range tests {
+		image := FlagImage{Flag: tt.flag}
+		if got := image.Replace(tt.image); got != tt.want {
+			t.Errorf("Replace(%s) for %s = %s, want %s", tt.image, tt.flag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package docker
+
+// Go tools installed at a version from a build arg, e.g.,
+// RUN go install example.com/tool/cmd/tool@${TOOL_VERSION}
+
+import (
+	"regexp"
+	"strings"
+)
+
+// goInstallRegex matches a package at a version from a build arg, e.g., example.com/tool@${TOOL_VERSION}
+var goInstallRegex = regexp.MustCompile(`([^\s"'=@]+)@\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?`)
+
+// GoInstalls maps build args to the package a RUN go install (or go get) installs with them
+func (d *Dockerfile) GoInstalls() map[string]string {
+	installs := map[string]string{}
+	for _, cmd := range d.Cmds["run"] {
+		text := strings.Join(cmd.Value, " ")
+		if !strings.Contains(text, "go install") && !strings.Contains(text, "go get") {
+			continue
+		}
+		for _, match := range goInstallRegex.FindAllStringSubmatch(text, -1) {
+			if _, ok := installs[match[2]]; !ok {
+				installs[match[2]] = match[1]
+			}
+		}
+	}
+	return installs
+}
@@ -0,0 +1,22 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGoInstalls(t *testing.T) {
+	content := `FROM golang:1.21
+ARG TOOL_VERSION=v1.2.0
+ARG LINT_VERSION=v1.55.0
+RUN go install example.com/tool/cmd/tool@${TOOL_VERSION} && \
+    go get github.com/org/lint@$LINT_VERSION
+RUN pip install app@${TOOL_VERSION}
+`
+	d, cleanup := parseDockerfile(t, content)
+	defer cleanup()
+	want := map[string]string{"TOOL_VERSION": "example.com/tool/cmd/tool", "LINT_VERSION": "github.com/org/lint"}
+	if got := d.GoInstalls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GoInstalls() = %v, want %v", got, want)
+	}
+}
@@ -44,6 +44,11 @@ type DockerHierarchy struct {
 	EndAtVersion    string
 	SkipVersions    []string
 	IncludeVersions []string
+	MaxTags         int
+	NewerThan       string
+	MinAge          string
+	VersionLabel    string
+	LabelMaxTags    int
 	tags            []string
 }
 
@@ -140,7 +145,11 @@ func (s *DockerHierarchyParser) Load(path string) {
 
 	// Look at each found path
 	for _, subpath := range paths {
-		conf := config.Load(subpath)
+		conf, err := config.Load(subpath)
+		if err != nil {
+			log.Printf("Skipping %s, %s\n", subpath, err)
+			continue
+		}
 
 		// If the dockerhierarchy key is missing, we cannot parse!
 		var emptyDockerHierarchy config.DockerHierarchy
@@ -161,6 +170,11 @@ func (s *DockerHierarchyParser) Load(path string) {
 			EndAtVersion:    conf.DockerHierarchy.Container.EndAt,
 			SkipVersions:    conf.DockerHierarchy.Container.Skips,
 			IncludeVersions: conf.DockerHierarchy.Container.Includes,
+			MaxTags:         conf.DockerHierarchy.Container.MaxTags,
+			NewerThan:       conf.DockerHierarchy.Container.NewerThan,
+			MinAge:          conf.DockerHierarchy.Container.MinAge,
+			VersionLabel:    conf.DockerHierarchy.Container.GetVersionLabel(),
+			LabelMaxTags:    conf.DockerHierarchy.Container.LabelMaxTags,
 			Path:            subpath,
 			Root:            path}
 
@@ -179,7 +193,13 @@ func (s *DockerHierarchyParser) Update(dryrun bool) error {
 	for _, root := range s.Roots {
 
 		// Get all versions (tags) based on filters and user preferences
-		versions := GetVersions(root.Container, root.Filters, root.StartAtVersion, root.EndAtVersion, root.SkipVersions, root.IncludeVersions)
+		var versions []string
+		if root.VersionLabel != "" {
+			versions = GetLabelVersions(root.Container, root.VersionLabel, root.Filters, root.StartAtVersion, root.EndAtVersion, root.SkipVersions, root.IncludeVersions, root.MaxTags, root.LabelMaxTags)
+		} else {
+			versions = GetVersions(root.Container, root.Filters, root.StartAtVersion, root.EndAtVersion, root.SkipVersions, root.IncludeVersions, root.MaxTags)
+		}
+		versions = FilterVersionsByAge(root.Container, versions, root.NewerThan, root.MinAge)
 
 		// At this point we have a list of versions we want.
 		// We now compare existing to those that need to be created
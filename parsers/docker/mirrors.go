@@ -0,0 +1,58 @@
+package docker
+
+// Mirror rules resolve references against one registry and write them for another
+
+import (
+	"strings"
+
+	"github.com/vsoch/uptodate/config"
+)
+
+// normalizePrefix makes a mirror prefix or upstream comparable to a FullName
+func normalizePrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	parts := strings.SplitN(prefix, "/", 2)
+	host := parts[0]
+
+	// Without a registry host, the prefix is on Docker Hub
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return DefaultRegistryHost + "/" + prefix
+	}
+	if host == "index.docker.io" || host == "registry-1.docker.io" {
+		parts[0] = "docker.io"
+	}
+	return strings.Join(parts, "/")
+}
+
+// replacePrefix swaps the prefix of a full name, if it matches
+func replacePrefix(fullName string, from string, to string) (string, bool) {
+	from = normalizePrefix(from)
+	if fullName != from && !strings.HasPrefix(fullName, from+"/") {
+		return fullName, false
+	}
+	return strings.Trim(to, "/") + strings.TrimPrefix(fullName, from), true
+}
+
+// mirrorLookup returns the name to look up for a reference written with a mirror prefix
+func mirrorLookup(mirrors []config.Mirror, ref Reference) string {
+	for _, mirror := range mirrors {
+		if name, ok := replacePrefix(ref.FullName(), mirror.Prefix, mirror.Upstream); ok {
+			return name
+		}
+	}
+	return ref.Name
+}
+
+// mirrorRewrite returns the name to write for a reference, using the prefix of a
+// mirror that rewrites its upstream. Otherwise the name is kept as written.
+func mirrorRewrite(mirrors []config.Mirror, ref Reference) string {
+	for _, mirror := range mirrors {
+		if !mirror.Rewrite {
+			continue
+		}
+		if name, ok := replacePrefix(ref.FullName(), mirror.Upstream, mirror.Prefix); ok {
+			return name
+		}
+	}
+	return ref.Name
+}
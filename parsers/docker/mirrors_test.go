@@ -0,0 +1,76 @@
+package docker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vsoch/uptodate/config"
+	"github.com/vsoch/uptodate/utils"
+)
+
+var testMirrors = []config.Mirror{
+	{Prefix: "mirror.corp/dockerhub", Upstream: "docker.io", Rewrite: true},
+	{Prefix: "mirror.corp/ghcr/", Upstream: "ghcr.io/"},
+}
+
+func TestMirrorLookup(t *testing.T) {
+	tests := map[string]string{
+		"mirror.corp/dockerhub/library/ubuntu:20.04": "docker.io/library/ubuntu",
+		"mirror.corp/dockerhub/vsoch/uptodate":       "docker.io/vsoch/uptodate",
+		"mirror.corp/ghcr/org/app":                   "ghcr.io/org/app",
+		"mirror.corp/other/app":                      "mirror.corp/other/app",
+		"mirror.corp/dockerhubx/app":                 "mirror.corp/dockerhubx/app",
+		"ubuntu":                                     "ubuntu",
+	}
+	for value, want := range tests {
+		ref, err := ParseReference(value)
+		if err != nil {
+			t.Fatalf("ParseReference(%q) returned an error: %s", value, err)
+		}
+		if got := mirrorLookup(testMirrors, ref); got != want {
+			t.Errorf("mirrorLookup(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestMirrorRewrite(t *testing.T) {
+	tests := map[string]string{
+		"ubuntu:20.04":           "mirror.corp/dockerhub/library/ubuntu",
+		"docker.io/vsoch/app":    "mirror.corp/dockerhub/vsoch/app",
+		"index.docker.io/ubuntu": "mirror.corp/dockerhub/library/ubuntu",
+
+		// Only mirrors that ask to rewrite do
+		"ghcr.io/org/app":          "ghcr.io/org/app",
+		"mirror.corp/ghcr/org/app": "mirror.corp/ghcr/org/app",
+	}
+	for value, want := range tests {
+		ref, err := ParseReference(value)
+		if err != nil {
+			t.Fatalf("ParseReference(%q) returned an error: %s", value, err)
+		}
+		if got := mirrorRewrite(testMirrors, ref); got != want {
+			t.Errorf("mirrorRewrite(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestGetTagsThroughMirror(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	tags := []string{"1.0", "1.1"}
+	server, _ := newTestRegistry(tags)
+	defer server.Close()
+
+	// The mirror isn't a registry we can reach, the tags come from upstream
+	client := NewRegistryClient()
+	client.Mirrors = []config.Mirror{{Prefix: "mirror.invalid/upstream", Upstream: strings.TrimPrefix(server.URL, "http://")}}
+	got, err := client.GetTags("mirror.invalid/upstream/team/app", 0)
+	if err != nil {
+		t.Fatalf("GetTags() through a mirror returned an error: %s", err)
+	}
+	if !reflect.DeepEqual(got, tags) {
+		t.Errorf("GetTags() through a mirror = %v, want %v", got, tags)
+	}
+}
@@ -0,0 +1,128 @@
+package docker
+
+// Digest policies decide which digest we pin for multi-architecture images
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Digest policy modes
+const (
+	DigestIndex    = "index"    // the digest of the index (manifest list), valid for all platforms
+	DigestPlatform = "platform" // the digest of the manifest for a single platform
+)
+
+// DefaultPlatform is used when a platform digest is wanted but none is specified
+var DefaultPlatform = "linux/amd64"
+
+// DigestPolicy holds a mode (index or platform) and platform (os/arch[/variant])
+type DigestPolicy struct {
+	Mode     string
+	Platform string
+}
+
+// NewDigestPolicy validates a mode and platform, a platform alone implies platform mode
+func NewDigestPolicy(mode string, platform string) (DigestPolicy, error) {
+	policy := DigestPolicy{Mode: mode, Platform: platform}
+	if policy.Mode == "" && platform != "" {
+		policy.Mode = DigestPlatform
+	}
+	if policy.Mode == "" {
+		policy.Mode = DigestIndex
+	}
+	if policy.Mode != DigestIndex && policy.Mode != DigestPlatform {
+		return policy, fmt.Errorf("%s is not a known digest policy, choices are %s or %s", mode, DigestIndex, DigestPlatform)
+	}
+	if platform != "" && strings.Count(platform, "/") < 1 {
+		return policy, fmt.Errorf("%s is not a valid platform, it should be os/arch[/variant]", platform)
+	}
+	return policy, nil
+}
+
+// GetPlatform returns the platform or the default
+func (p *DigestPolicy) GetPlatform() string {
+	if p.Platform == "" {
+		return DefaultPlatform
+	}
+	return p.Platform
+}
+
+// ForFlags returns the policy for a FROM. Directives (# uptodate: digest=platform platform=linux/arm64)
+// change the policy for the instruction, and a --platform flag wins unless it is a variable
+func (p DigestPolicy) ForFlags(flags []string, directives map[string]string) DigestPolicy {
+	if directives["digest"] != "" || directives["platform"] != "" {
+		mode := directives["digest"]
+		if mode == "" && p.Mode == DigestPlatform {
+			mode = DigestPlatform
+		}
+		policy, err := NewDigestPolicy(mode, directives["platform"])
+		if err != nil {
+			fmt.Printf("Ignoring digest directive: %s\n", err)
+		} else {
+			p = policy
+		}
+	}
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "--platform=") {
+			continue
+		}
+		platform := strings.TrimPrefix(flag, "--platform=")
+		if platform != "" && !strings.Contains(platform, "$") {
+			p.Platform = platform
+		}
+	}
+	return p
+}
+
+// GetDigest returns the digest of a tag according to the policy
+func (p *DigestPolicy) GetDigest(name string, tag string) (string, error) {
+	manifest, digest, err := DefaultRegistry.GetManifest(name, tag)
+	if err != nil {
+		return "", err
+	}
+
+	// A single platform image (or wanting the index) means we are done
+	if p.Mode != DigestPlatform || !manifest.IsIndex() {
+		return digest, nil
+	}
+	descriptor, ok := selectPlatform(manifest, p.GetPlatform())
+	if !ok {
+		return "", fmt.Errorf("%s:%s does not have a manifest for %s", name, tag, p.GetPlatform())
+	}
+	return descriptor.Digest, nil
+}
+
+// defaultVariants are the variants picked for an architecture when a platform doesn't give one
+var defaultVariants = map[string]string{
+	"arm":   "v7",
+	"arm64": "v8",
+}
+
+// selectPlatform finds the manifest in an index for a platform (os/arch[/variant])
+// Without a variant, the default variant for the architecture is preferred (e.g., v7 for arm)
+func selectPlatform(index Manifest, platform string) (Descriptor, bool) {
+	parts := strings.SplitN(platform, "/", 3)
+	matches := []Descriptor{}
+	for _, descriptor := range index.Manifests {
+		if descriptor.Platform == nil || len(parts) < 2 {
+			continue
+		}
+		if descriptor.Platform.OS != parts[0] || descriptor.Platform.Architecture != parts[1] {
+			continue
+		}
+		if len(parts) == 3 && descriptor.Platform.Variant != parts[2] {
+			continue
+		}
+		matches = append(matches, descriptor)
+	}
+	if len(matches) == 0 {
+		return Descriptor{}, false
+	}
+	for _, descriptor := range matches {
+		if descriptor.Platform.Variant == defaultVariants[parts[1]] {
+			return descriptor, true
+		}
+	}
+	return matches[0], true
+}
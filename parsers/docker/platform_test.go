@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestForFlags(t *testing.T) {
+	index := DigestPolicy{Mode: DigestIndex}
+	platform := DigestPolicy{Mode: DigestPlatform, Platform: "linux/amd64"}
+	tests := []struct {
+		name       string
+		policy     DigestPolicy
+		flags      []string
+		directives map[string]string
+		want       DigestPolicy
+	}{
+		{"default", index, []string{}, map[string]string{}, index},
+		{"platform flag", platform, []string{"--platform=linux/arm64"}, map[string]string{}, DigestPolicy{Mode: DigestPlatform, Platform: "linux/arm64"}},
+		{"variable platform flag", platform, []string{"--platform=$BUILDPLATFORM"}, map[string]string{}, platform},
+		{"digest directive", index, []string{}, map[string]string{"digest": "platform"}, DigestPolicy{Mode: DigestPlatform}},
+		{"platform directive", index, []string{}, map[string]string{"platform": "linux/arm64"}, DigestPolicy{Mode: DigestPlatform, Platform: "linux/arm64"}},
+		{"index directive", platform, []string{}, map[string]string{"digest": "index"}, index},
+		{"flag wins over directive", index, []string{"--platform=linux/arm/v7"}, map[string]string{"platform": "linux/arm64"}, DigestPolicy{Mode: DigestPlatform, Platform: "linux/arm/v7"}},
+		{"invalid directive", platform, []string{}, map[string]string{"digest": "manifest"}, platform},
+		{"invalid platform", index, []string{}, map[string]string{"platform": "arm64"}, index},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.ForFlags(tt.flags, tt.directives); got != tt.want {
+			t.Errorf("%s: ForFlags(%v, %v) = %+v, want %+v", tt.name, tt.flags, tt.directives, got, tt.want)
+		}
+	}
+}
+
+func TestNewDigestPolicy(t *testing.T) {
+	tests := []struct {
+		mode     string
+		platform string
+		want     DigestPolicy
+		wantErr  bool
+	}{
+		{"", "", DigestPolicy{Mode: DigestIndex}, false},
+		{"", "linux/arm64", DigestPolicy{Mode: DigestPlatform, Platform: "linux/arm64"}, false},
+		{"platform", "", DigestPolicy{Mode: DigestPlatform}, false},
+		{"manifest", "", DigestPolicy{}, true},
+		{"platform", "arm64", DigestPolicy{}, true},
+	}
+	for _, tt := range tests {
+		got, err := NewDigestPolicy(tt.mode, tt.platform)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NewDigestPolicy(%q, %q) = %+v, want an error", tt.mode, tt.platform, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NewDigestPolicy(%q, %q) = %+v, %v, want %+v", tt.mode, tt.platform, got, err, tt.want)
+		}
+	}
+}
+
+func TestSelectPlatform(t *testing.T) {
+	index := Manifest{Manifests: []Descriptor{
+		{Digest: "sha256:amd64", Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+		{Digest: "sha256:armv6", Platform: &Platform{OS: "linux", Architecture: "arm", Variant: "v6"}},
+		{Digest: "sha256:armv7", Platform: &Platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+		{Digest: "sha256:arm64", Platform: &Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		{Digest: "sha256:attestation"},
+	}}
+	tests := map[string]string{
+		"linux/amd64":   "sha256:amd64",
+		"linux/arm":     "sha256:armv7",
+		"linux/arm/v6":  "sha256:armv6",
+		"linux/arm/v7":  "sha256:armv7",
+		"linux/arm64":   "sha256:arm64",
+		"linux/arm/v5":  "",
+		"windows/amd64": "",
+		"linux":         "",
+	}
+	for platform, want := range tests {
+		descriptor, ok := selectPlatform(index, platform)
+		if ok != (want != "") || descriptor.Digest != want {
+			t.Errorf("selectPlatform(%q) = %q, %v, want %q", platform, descriptor.Digest, ok, want)
+		}
+	}
+
+	// Without the default variant, the first one for the architecture is used
+	v6 := Manifest{Manifests: index.Manifests[1:2]}
+	if descriptor, ok := selectPlatform(v6, "linux/arm"); !ok || descriptor.Digest != "sha256:armv6" {
+		t.Errorf("selectPlatform(linux/arm) without v7 = %q, %v, want sha256:armv6", descriptor.Digest, ok)
+	}
+}
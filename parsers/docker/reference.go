@@ -0,0 +1,119 @@
+package docker
+
+// Parse image references into registry, repository, tag, and digest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultRegistryHost is used for references without a registry
+var DefaultRegistryHost = "docker.io"
+
+// Patterns from the distribution reference grammar
+var (
+	repositoryRegex = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*)*$`)
+	tagRegex        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegex     = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]{32,}$`)
+	hostRegex       = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?\.?)+(?::[0-9]+)?$|^\[[a-fA-F0-9:]+\](?::[0-9]+)?$`)
+)
+
+// Reference is a parsed image reference like localhost:5000/team/app:1.2@sha256:...
+type Reference struct {
+	Name       string // The name as written, without tag or digest
+	Registry   string // The registry host (and port), e.g., docker.io
+	Repository string // The repository, e.g., library/ubuntu
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference, normalizing the registry and repository
+func ParseReference(value string) (Reference, error) {
+	ref := Reference{}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ref, fmt.Errorf("image reference is empty")
+	}
+	if strings.Contains(value, "$") {
+		return ref, fmt.Errorf("%s contains a variable", value)
+	}
+
+	// The digest is anything after the @
+	name := value
+	if strings.Contains(name, "@") {
+		parts := strings.SplitN(name, "@", 2)
+		name = parts[0]
+		ref.Digest = parts[1]
+		if !digestRegex.MatchString(ref.Digest) {
+			return ref, fmt.Errorf("%s does not have a valid digest", value)
+		}
+	}
+
+	// A tag follows the last colon, if it's after the last slash (otherwise it's a port)
+	lastSlash := strings.LastIndex(name, "/")
+	lastColon := strings.LastIndex(name, ":")
+	if lastColon > lastSlash {
+		ref.Tag = name[lastColon+1:]
+		name = name[:lastColon]
+		if !tagRegex.MatchString(ref.Tag) {
+			return ref, fmt.Errorf("%s does not have a valid tag", value)
+		}
+	}
+	ref.Name = name
+
+	// The first component is a registry if it looks like a host
+	ref.Registry = DefaultRegistryHost
+	repository := name
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:[") || parts[0] == "localhost" || parts[0] != strings.ToLower(parts[0])) {
+		ref.Registry = parts[0]
+		repository = parts[1]
+		if !hostRegex.MatchString(ref.Registry) {
+			return ref, fmt.Errorf("%s does not have a valid registry", value)
+		}
+	}
+	if ref.Registry == "index.docker.io" || ref.Registry == "registry-1.docker.io" {
+		ref.Registry = "docker.io"
+	}
+
+	// Official images on Docker Hub live under library
+	if ref.Registry == "docker.io" && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	if !repositoryRegex.MatchString(repository) {
+		return ref, fmt.Errorf("%s does not have a valid repository name", value)
+	}
+	ref.Repository = repository
+	return ref, nil
+}
+
+// FullName is the normalized registry and repository, e.g., docker.io/library/ubuntu
+func (r *Reference) FullName() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// GetTag returns the tag, defaulting to latest
+func (r *Reference) GetTag() string {
+	if r.Tag == "" {
+		return "latest"
+	}
+	return r.Tag
+}
+
+// String returns the reference as written, with any tag and digest
+func (r *Reference) String() string {
+	value := r.Name
+	if r.Tag != "" {
+		value += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		value += "@" + r.Digest
+	}
+	return value
+}
+
+// SameRepository determines if two references point to the same repository
+func (r *Reference) SameRepository(other Reference) bool {
+	return r.FullName() == other.FullName()
+}
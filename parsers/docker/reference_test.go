@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	digest := "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		value string
+		want  Reference
+	}{
+		{"ubuntu", Reference{Name: "ubuntu", Registry: "docker.io", Repository: "library/ubuntu"}},
+		{"ubuntu:20.04", Reference{Name: "ubuntu", Registry: "docker.io", Repository: "library/ubuntu", Tag: "20.04"}},
+		{"vsoch/uptodate:latest", Reference{Name: "vsoch/uptodate", Registry: "docker.io", Repository: "vsoch/uptodate", Tag: "latest"}},
+		{"docker.io/ubuntu", Reference{Name: "docker.io/ubuntu", Registry: "docker.io", Repository: "library/ubuntu"}},
+		{"index.docker.io/library/ubuntu", Reference{Name: "index.docker.io/library/ubuntu", Registry: "docker.io", Repository: "library/ubuntu"}},
+		{"ghcr.io/org/app:1.0", Reference{Name: "ghcr.io/org/app", Registry: "ghcr.io", Repository: "org/app", Tag: "1.0"}},
+		{"localhost/app", Reference{Name: "localhost/app", Registry: "localhost", Repository: "app"}},
+		{"localhost:5000/team/app", Reference{Name: "localhost:5000/team/app", Registry: "localhost:5000", Repository: "team/app"}},
+		{"localhost:5000/team/app:1.2", Reference{Name: "localhost:5000/team/app", Registry: "localhost:5000", Repository: "team/app", Tag: "1.2"}},
+		{"[::1]:5000/app:1.2", Reference{Name: "[::1]:5000/app", Registry: "[::1]:5000", Repository: "app", Tag: "1.2"}},
+		{"ubuntu@" + digest, Reference{Name: "ubuntu", Registry: "docker.io", Repository: "library/ubuntu", Digest: digest}},
+		{"localhost:5000/app:1.2@" + digest, Reference{Name: "localhost:5000/app", Registry: "localhost:5000", Repository: "app", Tag: "1.2", Digest: digest}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.value)
+		if err != nil {
+			t.Errorf("ParseReference(%q) returned an error: %s", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseReferenceInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"$BASE_IMAGE",
+		"ubuntu:${TAG}",
+		"Ubuntu",
+		"ubuntu:bad/tag",
+		"ubuntu@sha256:short",
+		"bad_host:5000:1/app",
+	}
+	for _, value := range tests {
+		if ref, err := ParseReference(value); err == nil {
+			t.Errorf("ParseReference(%q) = %+v, want an error", value, ref)
+		}
+	}
+}
+
+func TestReferenceString(t *testing.T) {
+	tests := []struct {
+		value   string
+		tag     string
+		full    string
+		written string
+	}{
+		{"ubuntu", "latest", "docker.io/library/ubuntu", "ubuntu"},
+		{"localhost:5000/team/app:1.2", "1.2", "localhost:5000/team/app", "localhost:5000/team/app:1.2"},
+	}
+	for _, tt := range tests {
+		ref, err := ParseReference(tt.value)
+		if err != nil {
+			t.Fatalf("ParseReference(%q) returned an error: %s", tt.value, err)
+		}
+		if got := ref.GetTag(); got != tt.tag {
+			t.Errorf("GetTag() for %q = %q, want %q", tt.value, got, tt.tag)
+		}
+		if got := ref.FullName(); got != tt.full {
+			t.Errorf("FullName() for %q = %q, want %q", tt.value, got, tt.full)
+		}
+		if got := ref.String(); got != tt.written {
+			t.Errorf("String() for %q = %q, want %q", tt.value, got, tt.written)
+		}
+	}
+}
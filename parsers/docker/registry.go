@@ -0,0 +1,479 @@
+package docker
+
+// A minimal client for the OCI distribution (registry v2) API. We only
+// need read access: tags, manifests, and blobs (e.g., image configs)
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vsoch/uptodate/config"
+	"github.com/vsoch/uptodate/utils"
+)
+
+// Media types for manifests and indexes we know how to parse
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// ManifestMediaTypes are sent in the Accept header for manifest requests
+var ManifestMediaTypes = []string{
+	MediaTypeOCIIndex,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifest,
+}
+
+// Platform describes the os and architecture of a manifest in an index
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Descriptor points to a manifest, config, or layer by digest
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest holds either an image manifest or an index (manifest list)
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// IsIndex determines if the manifest is an index (manifest list)
+func (m *Manifest) IsIndex() bool {
+	if m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerManifestList {
+		return true
+	}
+	return m.MediaType == "" && len(m.Manifests) > 0
+}
+
+// RegistryError is returned when a registry responds with an unexpected status
+type RegistryError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *RegistryError) Error() string {
+	return fmt.Sprintf("%s returned status %d", e.URL, e.StatusCode)
+}
+
+// IsNotFound determines if an error is a registry 404
+func IsNotFound(err error) bool {
+	regErr, ok := err.(*RegistryError)
+	return ok && regErr.StatusCode == http.StatusNotFound
+}
+
+// RegistryClient talks to registries directly, handling the token handshake
+type RegistryClient struct {
+
+	// Registries (host[:port]) that should be contacted over plain http
+	Insecure []string
+
+	// Credentials looks up credentials for a registry host
+	Credentials func(host string) Credentials
+
+	// Mirror rules, references with a mirror prefix are looked up upstream
+	Mirrors []config.Mirror
+
+	// Authorization headers keyed by host and scope, and credentials by host
+	authorization map[string]string
+	credentials   map[string]Credentials
+}
+
+// NewRegistryClient returns a client, reading insecure registries from the environment
+func NewRegistryClient() *RegistryClient {
+	client := RegistryClient{
+		Credentials:   GetCredentials,
+		authorization: map[string]string{},
+		credentials:   map[string]Credentials{},
+	}
+	insecure := os.Getenv("UPTODATE_INSECURE_REGISTRIES")
+	for _, host := range strings.Split(insecure, ",") {
+		host = strings.TrimSpace(host)
+		if host != "" {
+			client.Insecure = append(client.Insecure, host)
+		}
+	}
+	return &client
+}
+
+// DefaultRegistry is the client used by the docker parsers
+var DefaultRegistry = NewRegistryClient()
+
+// TagsPageSize is the number of tags we ask for in each page
+var TagsPageSize = 1000
+
+// How long cached responses are used without asking the registry again. Tags
+// can move, but anything requested by digest never changes.
+var (
+	TagsCacheTTL     = time.Hour
+	ManifestCacheTTL = time.Hour
+	DigestCacheTTL   = 30 * 24 * time.Hour
+)
+
+// GetTags lists tags for a repository, following pages until done or maxTags is reached
+// A maxTags of zero means no limit
+func (r *RegistryClient) GetTags(name string, maxTags int) ([]string, error) {
+	tags := []string{}
+	ref, err := r.parseName(name)
+	if err != nil {
+		return tags, err
+	}
+	host, repository := ref.Registry, ref.Repository
+
+	requestUrl := r.baseUrl(host) + "/v2/" + repository + "/tags/list?n=" + strconv.Itoa(TagsPageSize)
+
+	// A registry that links back to a page we've seen would otherwise never end
+	visited := map[string]bool{}
+	for requestUrl != "" {
+		if visited[requestUrl] {
+			fmt.Printf("%s links to a page of tags it already listed, stopping there.\n", name)
+			break
+		}
+		visited[requestUrl] = true
+		response, err := r.fetch(host, repository, requestUrl, []string{"application/json"}, TagsCacheTTL)
+		if err != nil {
+			return tags, err
+		}
+		listing := struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}{}
+		if err := json.Unmarshal(response.Body, &listing); err != nil {
+			return tags, err
+		}
+		tags = append(tags, listing.Tags...)
+
+		// Stop at the limit, even if there are more pages
+		if maxTags > 0 && len(tags) >= maxTags {
+			if len(tags) > maxTags || nextLink(response.Header) != "" {
+				fmt.Printf("%s has more than %d tags, only the first %d are used.\n", name, maxTags, maxTags)
+			}
+			return tags[:maxTags], nil
+		}
+		requestUrl, err = r.resolveLink(host, nextLink(response.Header))
+		if err != nil {
+			return tags, err
+		}
+	}
+	return tags, nil
+}
+
+// GetManifest returns the manifest (or index) for a tag or digest, along with its digest
+func (r *RegistryClient) GetManifest(name string, reference string) (Manifest, string, error) {
+	manifest := Manifest{}
+	ref, err := r.parseName(name)
+	if err != nil {
+		return manifest, "", err
+	}
+	host, repository := ref.Registry, ref.Repository
+
+	ttl := ManifestCacheTTL
+	if strings.HasPrefix(reference, "sha256:") {
+		ttl = DigestCacheTTL
+	}
+	response, err := r.get(host, repository, "/manifests/"+reference, ManifestMediaTypes, ttl)
+	if err != nil {
+		return manifest, "", err
+	}
+	err = json.Unmarshal(response.Body, &manifest)
+	if manifest.MediaType == "" {
+		manifest.MediaType = strings.Split(response.Header.Get("Content-Type"), ";")[0]
+	}
+
+	// Registries should give us the digest, otherwise calculate it
+	digest := response.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(response.Body))
+	}
+	return manifest, digest, err
+}
+
+// HasManifest asks the registry if a manifest exists right now, without the cache. A registry
+// garbage collects untagged digests, and a cached manifest would hide that.
+func (r *RegistryClient) HasManifest(name string, reference string) (bool, error) {
+	ref, err := r.parseName(name)
+	if err != nil {
+		return false, err
+	}
+	requestUrl := r.baseUrl(ref.Registry) + "/v2/" + ref.Repository + "/manifests/" + reference
+	response, err := r.authorizedRequest("HEAD", ref.Registry, ref.Repository, requestUrl, ManifestMediaTypes, map[string]string{})
+
+	// Some registries don't allow a HEAD, so ask for the manifest instead
+	if err == nil && (response.StatusCode == http.StatusMethodNotAllowed || response.StatusCode == http.StatusNotImplemented) {
+		response, err = r.authorizedRequest("GET", ref.Registry, ref.Repository, requestUrl, ManifestMediaTypes, map[string]string{})
+	}
+	if err != nil {
+		return false, err
+	}
+	switch response.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, &RegistryError{URL: requestUrl, StatusCode: response.StatusCode}
+}
+
+// GetBlob retrieves a blob (e.g., an image config) by digest
+func (r *RegistryClient) GetBlob(name string, digest string) ([]byte, error) {
+	ref, err := r.parseName(name)
+	if err != nil {
+		return []byte{}, err
+	}
+	response, err := r.get(ref.Registry, ref.Repository, "/blobs/"+digest, []string{}, DigestCacheTTL)
+	if err != nil {
+		return []byte{}, err
+	}
+	return response.Body, nil
+}
+
+// parseName parses the name of a repository, honoring mirror rules
+func (r *RegistryClient) parseName(name string) (Reference, error) {
+	ref, err := ParseReference(name)
+	if err != nil || len(r.Mirrors) == 0 {
+		return ref, err
+	}
+	return ParseReference(mirrorLookup(r.Mirrors, ref))
+}
+
+// baseUrl returns the scheme and host for the api of a registry
+func (r *RegistryClient) baseUrl(host string) string {
+
+	// Docker Hub serves the api from a different host
+	if host == "docker.io" || host == "index.docker.io" {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	hostname := strings.Split(host, ":")[0]
+	if hostname == "localhost" || hostname == "127.0.0.1" || strings.HasPrefix(host, "[::1]") || utils.IncludesString(host, r.Insecure) {
+		scheme = "http"
+	}
+	return scheme + "://" + host
+}
+
+// getCredentials looks up (and remembers) credentials for a host
+func (r *RegistryClient) getCredentials(host string) Credentials {
+	if creds, ok := r.credentials[host]; ok {
+		return creds
+	}
+	creds := Credentials{}
+	if r.Credentials != nil {
+		creds = r.Credentials(host)
+	}
+	r.credentials[host] = creds
+	return creds
+}
+
+// linkRegex matches the url of a Link header with rel="next"
+var linkRegex = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextLink returns the url of the next page from a Link header, if there is one
+func nextLink(header http.Header) string {
+	match := linkRegex.FindStringSubmatch(header.Get("Link"))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// resolveLink makes a (possibly relative) link absolute. A link to another host is refused,
+// since following it would send our credentials for the registry there
+func (r *RegistryClient) resolveLink(host string, link string) (string, error) {
+	if link == "" {
+		return "", nil
+	}
+	base := r.baseUrl(host)
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return base + "/" + strings.TrimPrefix(link, "/"), nil
+	}
+	linked, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	if linked.Host != strings.SplitN(base, "://", 2)[1] {
+		return "", fmt.Errorf("the next page of tags for %s is on another host, %s", host, linked.Host)
+	}
+	return link, nil
+}
+
+// get performs a GET against a path of the repository api
+func (r *RegistryClient) get(host string, repository string, path string, accept []string, ttl time.Duration) (*utils.Response, error) {
+	return r.fetch(host, repository, r.baseUrl(host)+"/v2/"+repository+path, accept, ttl)
+}
+
+// fetch performs a GET for a repository url through the cache
+func (r *RegistryClient) fetch(host string, repository string, requestUrl string, accept []string, ttl time.Duration) (*utils.Response, error) {
+	key := requestUrl + "|" + strings.Join(accept, ",")
+	response, err := utils.CachedRequest(key, ttl, func(headers map[string]string) (*utils.Response, error) {
+		return r.authorizedRequest("GET", host, repository, requestUrl, accept, headers)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, &RegistryError{URL: requestUrl, StatusCode: response.StatusCode}
+	}
+	return response, nil
+}
+
+// authorizedRequest performs a request, with the auth handshake if needed
+func (r *RegistryClient) authorizedRequest(method string, host string, repository string, requestUrl string, accept []string, headers map[string]string) (*utils.Response, error) {
+
+	scope := "repository:" + repository + ":pull"
+	authKey := host + "|" + scope
+	creds := r.getCredentials(host)
+
+	// A registry token can be sent directly
+	authorization, ok := r.authorization[authKey]
+	if !ok && creds.RegistryToken != "" {
+		authorization = "Bearer " + creds.RegistryToken
+	}
+
+	response, err := r.do(method, requestUrl, accept, authorization, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we aren't authorized, answer the challenge and try again
+	if response.StatusCode == http.StatusUnauthorized {
+		challenge := response.Header.Get("WWW-Authenticate")
+		authorization, err = r.authorize(challenge, scope, creds)
+		if err != nil {
+			return nil, err
+		}
+		r.authorization[authKey] = authorization
+		return r.do(method, requestUrl, accept, authorization, headers)
+	}
+	return response, nil
+}
+
+// do performs a single request and reads the response
+func (r *RegistryClient) do(method string, requestUrl string, accept []string, authorization string, headers map[string]string) (*utils.Response, error) {
+	req, err := http.NewRequest(method, requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return utils.Do(req)
+}
+
+// challengeRegex matches key="value" pairs in a WWW-Authenticate header
+var challengeRegex = regexp.MustCompile(`([a-zA-Z]+)="([^"]*)"`)
+
+// authorize answers an auth challenge, returning an Authorization header
+func (r *RegistryClient) authorize(challenge string, scope string, creds Credentials) (string, error) {
+	lowered := strings.ToLower(challenge)
+
+	// Basic auth is sent directly to the registry
+	if strings.HasPrefix(lowered, "basic") {
+		if creds.Username == "" && creds.Password == "" {
+			return "", fmt.Errorf("registry requires basic auth, but no credentials were found")
+		}
+		return "Basic " + basicAuth(creds.Username, creds.Password), nil
+	}
+	if !strings.HasPrefix(lowered, "bearer ") {
+		return "", fmt.Errorf("unsupported registry auth challenge: %q", challenge)
+	}
+	params := map[string]string{}
+	for _, match := range challengeRegex.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	realm, ok := params["realm"]
+	if !ok {
+		return "", fmt.Errorf("auth challenge is missing a realm: %q", challenge)
+	}
+
+	// Prefer the scope the registry asked for
+	if value, ok := params["scope"]; ok {
+		scope = value
+	}
+	token, err := r.getToken(realm, params["service"], scope, creds)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
+// getToken requests a bearer token from an auth server realm
+func (r *RegistryClient) getToken(realm string, service string, scope string, creds Credentials) (string, error) {
+	query := url.Values{}
+	query.Set("scope", scope)
+	if service != "" {
+		query.Set("service", service)
+	}
+
+	var req *http.Request
+	var err error
+
+	// An identity token is exchanged with an OAuth2 refresh grant
+	if creds.IdentityToken != "" {
+		query.Set("grant_type", "refresh_token")
+		query.Set("refresh_token", creds.IdentityToken)
+		query.Set("client_id", "uptodate")
+		req, err = http.NewRequest("POST", realm, strings.NewReader(query.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequest("GET", realm+"?"+query.Encode(), nil)
+		if err == nil && (creds.Username != "" || creds.Password != "") {
+			req.Header.Set("Authorization", "Basic "+basicAuth(creds.Username, creds.Password))
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	response, err := utils.Do(req)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", &RegistryError{URL: realm, StatusCode: response.StatusCode}
+	}
+	tokens := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	err = json.Unmarshal(response.Body, &tokens)
+	if tokens.Token == "" {
+		return tokens.AccessToken, err
+	}
+	return tokens.Token, err
+}
+
+// basicAuth encodes a username and password for an Authorization header
+func basicAuth(username string, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
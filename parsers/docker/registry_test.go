@@ -0,0 +1,171 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+// newTestRegistry serves team/app with tags in pages of two, behind a token handshake
+func newTestRegistry(tags []string) (*httptest.Server, *int) {
+	tokens := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:team/app:pull" || r.URL.Query().Get("service") != "test" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			tokens++
+			w.Write([]byte(`{"token": "secret"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:team/app:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v2/team/app/tags/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// Each page starts after the last tag of the previous one
+		start := 0
+		if last := r.URL.Query().Get("last"); last != "" {
+			for i, tag := range tags {
+				if tag == last {
+					start = i + 1
+				}
+			}
+		}
+		end := start + 2
+		if end >= len(tags) {
+			end = len(tags)
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`</v2/team/app/tags/list?n=2&last=%s>; rel="next"`, tags[end-1]))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"name": "team/app", "tags": tags[start:end]})
+	}))
+	return server, &tokens
+}
+
+func TestGetTags(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	tags := []string{"1.0", "1.1", "2.0", "2.1", "3.0"}
+	server, tokens := newTestRegistry(tags)
+	defer server.Close()
+	name := strings.TrimPrefix(server.URL, "http://") + "/team/app"
+
+	tests := []struct {
+		name    string
+		maxTags int
+		want    []string
+	}{
+		{"all pages", 0, tags},
+		{"stops within a page", 3, []string{"1.0", "1.1", "2.0"}},
+		{"stops at a page", 2, []string{"1.0", "1.1"}},
+		{"more than there are", 10, tags},
+	}
+	client := NewRegistryClient()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.GetTags(name, tt.maxTags)
+			if err != nil {
+				t.Fatalf("GetTags(%q, %d) returned an error: %s", name, tt.maxTags, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetTags(%q, %d) = %v, want %v", name, tt.maxTags, got, tt.want)
+			}
+		})
+	}
+
+	// The token is asked for once, and reused for every page after
+	if *tokens != 1 {
+		t.Errorf("asked for a token %d times, want 1", *tokens)
+	}
+}
+
+func TestGetManifestNotFound(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	server, _ := newTestRegistry([]string{"1.0"})
+	defer server.Close()
+
+	_, _, err := NewRegistryClient().GetManifest(strings.TrimPrefix(server.URL, "http://")+"/team/app", "1.0")
+	if !IsNotFound(err) {
+		t.Errorf("GetManifest for a missing manifest returned %v, want a not found error", err)
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{`</v2/team/app/tags/list?n=2&last=b>; rel="next"`, "/v2/team/app/tags/list?n=2&last=b"},
+		{`<https://example.com/v2/app/tags/list?last=b>;rel=next`, "https://example.com/v2/app/tags/list?last=b"},
+		{`</v2/team/app/tags/list?last=a>; rel="prev"`, ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set("Link", tt.header)
+		if got := nextLink(header); got != tt.want {
+			t.Errorf("nextLink(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestGetTagsLinks(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	link := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", link)
+		json.NewEncoder(w).Encode(map[string]interface{}{"name": "app", "tags": []string{r.URL.Query().Get("last")}})
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	// A page that links back to the first one stops instead of looping
+	link = `</v2/app/tags/list?n=1000>; rel="next"`
+	tags, err := NewRegistryClient().GetTags(host+"/app", 0)
+	if err != nil || len(tags) != 1 {
+		t.Errorf("GetTags() for a loop of links = %v, %v, want one page", tags, err)
+	}
+
+	// A link to another host is not followed
+	link = `<http://other.example.com/v2/app/tags/list?last=a>; rel="next"`
+	if tags, err := NewRegistryClient().GetTags(host+"/app", 0); err == nil {
+		t.Errorf("GetTags() followed a link to another host, got %v", tags)
+	}
+
+	client := NewRegistryClient()
+	tests := []struct {
+		link    string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"/v2/app/tags/list?last=a", server.URL + "/v2/app/tags/list?last=a", false},
+		{server.URL + "/v2/app/tags/list?last=a", server.URL + "/v2/app/tags/list?last=a", false},
+		{"https://other.example.com/v2/app/tags/list?last=a", "", true},
+	}
+	for _, tt := range tests {
+		got, err := client.resolveLink(host, tt.link)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolveLink(%q) = %q, %v, want %q", tt.link, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package docker
+
+// Verify cosign signatures for a digest, using a public key. Signatures are
+// found in the same repository under the tag sha256-<hex>.sig, and we don't
+// consult a transparency log.
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+)
+
+// Cosign signature annotations and payload types
+const (
+	CosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	CosignSimpleSigningType   = "application/vnd.dev.cosign.simplesigning.v1+json"
+	CosignPayloadType         = "cosign container image signature"
+)
+
+// SimpleSigning is the signed payload, naming the digest that was signed
+type SimpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// SignatureVerifier accepts a digest only if it has a signature from the key
+type SignatureVerifier struct {
+	KeyPath string
+	Key     crypto.PublicKey
+}
+
+// NewSignatureVerifier reads a PEM encoded public key (e.g., cosign.pub)
+func NewSignatureVerifier(path string) (*SignatureVerifier, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded public key", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid public key: %s", path, err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, fmt.Errorf("%s has an unsupported key type %T", path, key)
+	}
+	return &SignatureVerifier{KeyPath: path, Key: key}, nil
+}
+
+// SignatureTag is the tag cosign stores the signatures for a digest under
+func SignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// Verify checks that the digest of an image (name without tag) has a valid signature
+func (v *SignatureVerifier) Verify(name string, digest string) error {
+	manifest, _, err := DefaultRegistry.GetManifest(name, SignatureTag(digest))
+	if IsNotFound(err) {
+		return fmt.Errorf("%s@%s is not signed", name, digest)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Any one layer with a valid signature is enough
+	reason := fmt.Errorf("%s@%s has no signatures", name, digest)
+	for _, layer := range manifest.Layers {
+		signature, ok := layer.Annotations[CosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		if err := v.verifyLayer(name, digest, layer, signature); err != nil {
+			reason = fmt.Errorf("%s@%s has no valid signature: %s", name, digest, err)
+			continue
+		}
+		return nil
+	}
+	return reason
+}
+
+// verifyLayer verifies the signature of one payload, and that it names the digest
+func (v *SignatureVerifier) verifyLayer(name string, digest string, layer Descriptor, signature string) error {
+	if layer.MediaType != CosignSimpleSigningType {
+		return fmt.Errorf("unknown payload type %s", layer.MediaType)
+	}
+	payload, err := DefaultRegistry.GetBlob(name, layer.Digest)
+	if err != nil {
+		return err
+	}
+	if layer.Digest != fmt.Sprintf("sha256:%x", sha256.Sum256(payload)) {
+		return fmt.Errorf("payload does not match %s", layer.Digest)
+	}
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("signature is not base64: %s", err)
+	}
+	if err := v.verifySignature(payload, raw); err != nil {
+		return err
+	}
+
+	// The signature is good, but is it for this digest?
+	signed := SimpleSigning{}
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return fmt.Errorf("payload is not valid: %s", err)
+	}
+	if signed.Critical.Type != CosignPayloadType {
+		return fmt.Errorf("payload type is %s", signed.Critical.Type)
+	}
+	if signed.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("signature is for %s", signed.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
+
+// verifySignature verifies a signature of a payload with the public key
+func (v *SignatureVerifier) verifySignature(payload []byte, signature []byte) error {
+	hashed := sha256.Sum256(payload)
+	switch key := v.Key.(type) {
+	case *ecdsa.PublicKey:
+		// The signature is ASN.1 encoded r and s
+		var rs struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &rs); err != nil || !ecdsa.Verify(key, hashed[:], rs.R, rs.S) {
+			return fmt.Errorf("signature does not match %s", v.KeyPath)
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature); err != nil {
+			return fmt.Errorf("signature does not match %s", v.KeyPath)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, signature) {
+			return fmt.Errorf("signature does not match %s", v.KeyPath)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package docker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+// signedPayload signs a simple signing payload for a digest
+func signedPayload(t *testing.T, key *ecdsa.PrivateKey, digest string) ([]byte, string) {
+	signed := SimpleSigning{}
+	signed.Critical.Type = CosignPayloadType
+	signed.Critical.Image.DockerManifestDigest = digest
+	payload, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := sha256.Sum256(payload)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload, base64.StdEncoding.EncodeToString(signature)
+}
+
+// writePublicKey writes the PEM encoded public key of a private key
+func writePublicKey(t *testing.T, dir string, name string, key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerify(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	signedDigest := "sha256:" + strings.Repeat("a", 64)
+	otherDigest := "sha256:" + strings.Repeat("b", 64)
+	unsignedDigest := "sha256:" + strings.Repeat("c", 64)
+
+	// The signature for b is really for a, which is valid but names the wrong digest
+	payloads := map[string][]byte{}
+	signatures := map[string]string{}
+	payloads[signedDigest], signatures[signedDigest] = signedPayload(t, key, signedDigest)
+	payloads[otherDigest], signatures[otherDigest] = signedPayload(t, key, signedDigest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for digest, payload := range payloads {
+			layerDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(payload))
+			switch r.URL.Path {
+			case "/v2/app/manifests/" + SignatureTag(digest):
+				manifest := Manifest{SchemaVersion: 2, MediaType: MediaTypeOCIManifest, Layers: []Descriptor{{
+					MediaType:   CosignSimpleSigningType,
+					Digest:      layerDigest,
+					Size:        int64(len(payload)),
+					Annotations: map[string]string{CosignSignatureAnnotation: signatures[digest]},
+				}}}
+				w.Header().Set("Content-Type", MediaTypeOCIManifest)
+				json.NewEncoder(w).Encode(manifest)
+				return
+			case "/v2/app/blobs/" + layerDigest:
+				w.Write(payload)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	name := strings.TrimPrefix(server.URL, "http://") + "/app"
+
+	tests := []struct {
+		name   string
+		key    *ecdsa.PrivateKey
+		digest string
+		valid  bool
+	}{
+		{"signed", key, signedDigest, true},
+		{"signed for another digest", key, otherDigest, false},
+		{"signed by another key", other, signedDigest, false},
+		{"not signed", key, unsignedDigest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewSignatureVerifier(writePublicKey(t, dir, tt.name+".pub", tt.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = verifier.Verify(name, tt.digest)
+			if tt.valid && err != nil {
+				t.Errorf("Verify(%s) returned an error: %s", tt.digest, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("Verify(%s) did not return an error", tt.digest)
+			}
+		})
+	}
+}
+
+func TestSignatureTag(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	want := "sha256-" + strings.Repeat("a", 64) + ".sig"
+	if got := SignatureTag(digest); got != want {
+		t.Errorf("SignatureTag(%q) = %q, want %q", digest, got, want)
+	}
+}
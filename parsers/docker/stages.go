@@ -0,0 +1,74 @@
+package docker
+
+// A model of the build stages of a multi-stage Dockerfile
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Stage is a build stage, started by a FROM
+type Stage struct {
+	Index  int    `json:"index"`
+	Name   string `json:"name,omitempty"` // From FROM <image> AS <name>, lowercase
+	Image  string `json:"image"`          // The image or stage as written
+	Parent int    `json:"parent"`         // Index of the stage this builds on, -1 for an image
+	LineNo int    `json:"line"`
+}
+
+// IsImage determines if the stage starts from an image (and not another stage)
+func (s *Stage) IsImage() bool {
+	return s.Parent < 0
+}
+
+// Describe the stage for printing, e.g., 1 final (from stage 0 builder)
+func (s *Stage) Describe() string {
+	description := strconv.Itoa(s.Index)
+	if s.Name != "" {
+		description += " " + s.Name
+	}
+	if s.IsImage() {
+		return description + " (from image " + s.Image + ")"
+	}
+	return description + " (from stage " + strconv.Itoa(s.Parent) + " " + s.Image + ")"
+}
+
+// Stages returns the build stages, in order
+func (d *Dockerfile) Stages() []Stage {
+
+	// If we don't have commands yet, try to parse
+	if len(d.Cmds) == 0 {
+		d.ParseCommands()
+	}
+
+	stages := []Stage{}
+	for i, from := range d.Cmds["from"] {
+		stage := Stage{Index: i, Image: from.Value[0], Parent: -1, LineNo: from.StartLine}
+		if len(from.Value) > 2 && strings.ToLower(from.Value[1]) == "as" {
+			stage.Name = strings.ToLower(from.Value[2])
+		}
+
+		// Stage names are case insensitive, and must be declared before use
+		if parent, ok := findStage(stages, from.Value[0]); ok {
+			stage.Parent = parent.Index
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
+
+// findStage finds an earlier stage by name
+func findStage(stages []Stage, name string) (Stage, bool) {
+	name = strings.ToLower(name)
+	for _, stage := range stages {
+		if stage.Name != "" && stage.Name == name {
+			return stage, true
+		}
+	}
+	return Stage{}, false
+}
+
+// isStageReference determines if a FROM (by index) builds on an earlier stage
+func isStageReference(stages []Stage, index int) bool {
+	return index < len(stages) && !stages[index].IsImage()
+}
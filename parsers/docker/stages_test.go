@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStages(t *testing.T) {
+	d, cleanup := parseDockerfile(t, `FROM golang:1.17 AS Builder
+RUN go build
+FROM builder AS test
+FROM scratch
+FROM alpine:3.18 AS final
+COPY --from=builder /app /app
+FROM final
+`)
+	defer cleanup()
+
+	want := []Stage{
+		{Index: 0, Name: "builder", Image: "golang:1.17", Parent: -1, LineNo: 1},
+		{Index: 1, Name: "test", Image: "builder", Parent: 0, LineNo: 3},
+		{Index: 2, Image: "scratch", Parent: -1, LineNo: 4},
+		{Index: 3, Name: "final", Image: "alpine:3.18", Parent: -1, LineNo: 5},
+		{Index: 4, Image: "final", Parent: 3, LineNo: 7},
+	}
+	stages := d.Stages()
+	if !reflect.DeepEqual(stages, want) {
+		t.Fatalf("Stages() = %+v, want %+v", stages, want)
+	}
+	if got := stages[1].Describe(); got != "1 test (from stage 0 builder)" {
+		t.Errorf("Describe() = %q", got)
+	}
+	if got := stages[3].Describe(); got != "3 final (from image alpine:3.18)" {
+		t.Errorf("Describe() = %q", got)
+	}
+	for i, want := range []bool{false, true, false, false, true} {
+		if got := isStageReference(stages, i); got != want {
+			t.Errorf("isStageReference(%d) = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestStagesDeclaredLater(t *testing.T) {
+
+	// A stage can only be used after it is declared, so this is an image named later
+	d, cleanup := parseDockerfile(t, "FROM later\nFROM alpine:3.18 AS later\n")
+	defer cleanup()
+	if stages := d.Stages(); !stages[0].IsImage() {
+		t.Errorf("Stages() = %+v, want the first FROM to be an image", stages)
+	}
+}
+
+func TestUpdateFromsSkipsStages(t *testing.T) {
+
+	// Only images are looked up, and there are none here
+	d, cleanup := parseDockerfile(t, "FROM scratch AS base\nFROM base AS build\nFROM build\n")
+	defer cleanup()
+	d.UpdateFroms()
+	if len(d.Updates) != 0 {
+		t.Errorf("UpdateFroms() = %+v, want no updates for stages", d.Updates)
+	}
+}
@@ -0,0 +1,60 @@
+package docker
+
+// The syntax parser directive names the BuildKit frontend image, e.g.,
+// # syntax=docker/dockerfile:1.4
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/vsoch/uptodate/parsers"
+	"github.com/vsoch/uptodate/utils"
+)
+
+// parserDirectiveRegex matches a parser directive, which can only be at the top of a file
+var parserDirectiveRegex = regexp.MustCompile(`^#\s*([a-zA-Z][a-zA-Z0-9]*)\s*=\s*(\S+)\s*$`)
+
+// SyntaxDirective returns the syntax parser directive as a command, if there is one.
+// The value of the command is the frontend image.
+func (d *Dockerfile) SyntaxDirective() (Command, bool) {
+	if d.Raw == "" {
+		d.Raw = utils.ReadFile(d.Path)
+	}
+	for i, line := range strings.Split(d.Raw, "\n") {
+		line = strings.TrimRight(line, "\r")
+		match := parserDirectiveRegex.FindStringSubmatch(line)
+		if match == nil {
+			break
+		}
+		if strings.ToLower(match[1]) == "syntax" {
+			return Command{Cmd: "syntax", Original: line, StartLine: i + 1, EndLine: i + 1, Value: []string{match[2]}}, true
+		}
+	}
+	return Command{}, false
+}
+
+// UpdateSyntax pins (or refreshes) the frontend image of the syntax directive.
+// It's always the index digest, since the frontend runs on the build host.
+func (d *Dockerfile) UpdateSyntax() {
+	directive, ok := d.SyntaxDirective()
+	if !ok || strings.Contains(directive.Value[0], "$") {
+		return
+	}
+	newUpdate := UpdateFrom(directive.Value, DigestPolicy{Mode: DigestIndex})
+	if reflect.DeepEqual(newUpdate, parsers.Update{}) {
+		return
+	}
+
+	// The whole line is the token, the image may directly follow the =
+	image := directive.Value[0]
+	index := strings.LastIndex(directive.Original, image)
+	updated := directive.Original[:index] + newUpdate.Updated + directive.Original[index+len(image):]
+	verified := d.verifyUpdate(newUpdate)
+	newUpdate = tokenUpdate(directive, directive.Original, updated)
+	if verified {
+		d.Updates = append(d.Updates, newUpdate)
+	} else {
+		d.Unsigned = append(d.Unsigned, newUpdate)
+	}
+}
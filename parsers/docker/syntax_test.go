@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+func TestSyntaxDirective(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"# syntax=docker/dockerfile:1.4\nFROM ubuntu\n", "docker/dockerfile:1.4"},
+		{"#escape=`\n# Syntax = docker/dockerfile:1\nFROM ubuntu\n", "docker/dockerfile:1"},
+		{"# A comment\n# syntax=docker/dockerfile:1.4\nFROM ubuntu\n", ""},
+		{"FROM ubuntu\n# syntax=docker/dockerfile:1.4\n", ""},
+	}
+	for _, tt := range tests {
+		d := Dockerfile{Raw: tt.content}
+		directive, ok := d.SyntaxDirective()
+		if ok != (tt.want != "") || (ok && directive.Value[0] != tt.want) {
+			t.Errorf("SyntaxDirective() for %q = %+v, %v, want %q", tt.content, directive, ok, tt.want)
+		}
+	}
+}
+
+func TestUpdateSyntax(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	pinned := "sha256:" + strings.Repeat("a", 64)
+	server := newDigestRegistry(pinned, pinned)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	d, cleanup := parseDockerfile(t, "# syntax="+host+"/app:1.0\nFROM scratch\n")
+	defer cleanup()
+	d.UpdateSyntax()
+	want := "# syntax=" + host + "/app:1.0@" + pinned
+	if len(d.Updates) != 1 || d.Updates[0].Type != "syntax" || d.Updates[0].LineNo != 0 || d.Updates[0].Updated != want {
+		t.Errorf("UpdateSyntax() = %+v, want %s on the first line", d.Updates, want)
+	}
+}
@@ -90,7 +90,7 @@ func GetAllChanges(path string, main string) []GitChange {
 	if refName == main {
 		prevCommit, err = commit.Parent(0)
 		if err != nil {
-			log.Fatal("Issue getting previous commit!: %s\n", err)
+			log.Fatalf("Issue getting previous commit!: %s\n", err)
 		}
 	} else {
 		prevCommit = getComparisonCommit(repo, main)
@@ -7,127 +7,114 @@ import (
 	"github.com/vsoch/uptodate/parsers"
 )
 
-// UpdateCommitBuildArg will update a github commit build arg
-// Should be called from docker.go UpdateArg to ensure intiial checks
-// ARG uptodate_github_commit_<org>__<name>__branch=<release-tag>
-func UpdateCommitBuildArg(values []string) parsers.Update {
+// CommitUpdater updates a github commit build arg
+// ARG uptodate_github_commit_<org>__<name>__<branch>=<commit>
+// # uptodate: github-commit <org>/<name> [branch]
+type CommitUpdater struct{}
 
-	// This is the full argument with =
-	arg := values[0]
-
-	// Keep the original for later comparison
-	original := strings.Join(values, " ")
+// ReleaseUpdater updates a github release build arg
+// ARG uptodate_github_release_<org>__<name>=<release-tag>
+// # uptodate: github-release <org>/<name>
+type ReleaseUpdater struct{}
 
-	// We will return an update, empty if none
-	update := parsers.Update{}
+func init() {
+	parsers.RegisterUpdater(&ReleaseUpdater{})
+	parsers.RegisterUpdater(&CommitUpdater{})
+}
 
-	// Split into buildarg name and value
-	parts := strings.SplitN(arg, "=", 2)
-	name := parts[0]
+// Name of the updater, for directives
+func (u *CommitUpdater) Name() string {
+	return "github-commit"
+}
 
-	fmt.Printf("Found github commit build arg prefix %s\n", arg)
-	name = strings.Replace(name, "uptodate_github_commit_", "", 1)
+// Match a build arg named with the github commit prefix
+func (u *CommitUpdater) Match(name string) bool {
+	return strings.HasPrefix(name, "uptodate_github_commit")
+}
 
-	// The repository name must be separated by __
-	if strings.Count(name, "__") != 2 {
-		fmt.Printf("Cannot find double underscore to separate org from repo name, and then branch: %s", name)
-		return update
-	}
-	orgRepoBranch := strings.SplitN(name, "__", 3)
-	org := orgRepoBranch[0]
-	repo := orgRepoBranch[1]
-	branch := orgRepoBranch[2]
-
-	// Organization __ Repository
-	if org == "" || repo == "" || branch == "" {
-		fmt.Printf("Org (%s), repository (%s), or branch (%s) is empty, cannot parse.", org, repo, branch)
-		return update
+// Latest returns the newest commit of the branch (the default branch if not given)
+func (u *CommitUpdater) Latest(name string, value string, args []string, filter string) (string, error) {
+	var repository, branch string
+	if len(args) > 0 {
+		if len(args) > 2 || strings.Count(args[0], "/") != 1 {
+			return "", fmt.Errorf("the github-commit source needs an <org>/<repo> and optional branch")
+		}
+		repository = args[0]
+		if len(args) == 2 {
+			branch = args[1]
+		}
+	} else {
+		fmt.Printf("Found github commit build arg prefix %s\n", name)
+		name = strings.Replace(name, "uptodate_github_commit_", "", 1)
+
+		// The repository name must be separated by __
+		if strings.Count(name, "__") != 2 {
+			return "", fmt.Errorf("cannot find double underscore to separate org from repo name, and then branch: %s", name)
+		}
+		orgRepoBranch := strings.SplitN(name, "__", 3)
+		org := orgRepoBranch[0]
+		repo := orgRepoBranch[1]
+		branch = orgRepoBranch[2]
+
+		// Organization __ Repository
+		if org == "" || repo == "" || branch == "" {
+			return "", fmt.Errorf("org (%s), repository (%s), or branch (%s) is empty, cannot parse", org, repo, branch)
+		}
+		repository = org + "/" + repo
 	}
 
-	repository := org + "/" + repo
-	commits := GetCommits(repository, branch)
-
 	// The first in the list is the newest commit
+	commits := GetCommits(repository, branch)
 	if len(commits) == 0 {
-		fmt.Printf("%s has no commits, cannot update.", repository)
-		return update
-	}
-
-	commit := commits[0]
-	updated := parts[0] + "=" + commit.SHA
-
-	// Add original content back
-	for _, extra := range values[1:] {
-		updated += " " + extra
-	}
-
-	// If the updated version is different from the original, update
-	if updated != original {
-		update = parsers.Update{Original: original, Updated: updated}
-	} else {
-		fmt.Println("No difference between:", updated, original)
+		return "", fmt.Errorf("%s has no commits", repository)
 	}
-	return update
-
+	return commits[0].SHA, nil
 }
 
-// UpdateReleaseBuildArg will update a github release build arg
-// Should be called from docker.go UpdateArg to ensure intiial checks
-// ARG uptodate_github_release_<org>__<name>=<release-tag>
-func UpdateReleaseBuildArg(values []string) parsers.Update {
-
-	// This is the full argument with =
-	arg := values[0]
-
-	// Keep the original for later comparison
-	original := strings.Join(values, " ")
-
-	// Split into buildarg name and value
-	parts := strings.SplitN(arg, "=", 2)
-	name := parts[0]
-
-	// We will return an update, empty if none
-	update := parsers.Update{}
+// Name of the updater, for directives
+func (u *ReleaseUpdater) Name() string {
+	return "github-release"
+}
 
-	fmt.Printf("Found github release build arg prefix %s\n", arg)
-	name = strings.Replace(name, "uptodate_github_release_", "", 1)
+// Match a build arg named with the github release prefix
+func (u *ReleaseUpdater) Match(name string) bool {
+	return strings.HasPrefix(name, "uptodate_github_release")
+}
 
-	// The repository name must be separated by __
-	if !strings.Contains(name, "__") {
-		fmt.Printf("Cannot find double underscore to separate org from repo name: %s", name)
-		return update
-	}
-	orgRepo := strings.SplitN(name, "__", 2)
-	org := orgRepo[0]
-	repo := orgRepo[1]
-
-	// Organization __ Repository
-	if org == "" || repo == "" {
-		fmt.Printf("Org (%s) or repository (%s) is empty, cannot parse.", org, repo)
-		return update
+// Latest returns the newest release (with a name matching a filter, if given)
+func (u *ReleaseUpdater) Latest(name string, value string, args []string, filter string) (string, error) {
+	var repository string
+	if len(args) > 0 {
+		if len(args) != 1 || strings.Count(args[0], "/") != 1 {
+			return "", fmt.Errorf("the github-release source needs an <org>/<repo>")
+		}
+		repository = args[0]
+	} else {
+		fmt.Printf("Found github release build arg prefix %s\n", name)
+		name = strings.Replace(name, "uptodate_github_release_", "", 1)
+
+		// The repository name must be separated by __
+		if !strings.Contains(name, "__") {
+			return "", fmt.Errorf("cannot find double underscore to separate org from repo name: %s", name)
+		}
+		orgRepo := strings.SplitN(name, "__", 2)
+		org := orgRepo[0]
+		repo := orgRepo[1]
+
+		// Organization __ Repository
+		if org == "" || repo == "" {
+			return "", fmt.Errorf("org (%s) or repository (%s) is empty, cannot parse", org, repo)
+		}
+		repository = org + "/" + repo
 	}
-	repository := org + "/" + repo
-	releases := GetReleases(repository)
 
 	// The first in the list is the newest release
-	if len(releases) == 0 {
-		fmt.Printf("%s has no releases, cannot update.", repository)
-		return update
+	names := []string{}
+	for _, release := range GetReleases(repository) {
+		names = append(names, release.Name)
 	}
-	release := releases[0]
-	updated := parts[0] + "=" + release.Name
-
-	// Add original content back
-	for _, extra := range values[1:] {
-		updated += " " + extra
+	if len(names) == 0 {
+		return "", fmt.Errorf("%s has no releases", repository)
 	}
-
-	// If the updated version is different from the original, update
-	if updated != original {
-		update = parsers.Update{Original: original, Updated: updated}
-	} else {
-		fmt.Println("No difference between:", updated, original)
-	}
-
-	return update
+	return parsers.FirstMatch(names, filter)
 }
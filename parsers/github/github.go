@@ -4,15 +4,20 @@ import (
 	"encoding/json"
 	"github.com/vsoch/uptodate/utils"
 	"log"
+	neturl "net/url"
+	"time"
 )
 
+// CacheTTL is how long releases and commits are cached
+var CacheTTL = time.Hour
+
 func GetReleases(name string) Releases {
 
 	url := "https://api.github.com/repos/" + name + "/releases"
 
 	headers := make(map[string]string)
 	headers["Accept"] = "application/vnd.github.v3+json"
-	response := utils.GetRequest(url, headers)
+	response := utils.GetCachedRequest(url, headers, CacheTTL)
 
 	// The response gets parsed into a spack package
 	releases := Releases{}
@@ -26,10 +31,14 @@ func GetReleases(name string) Releases {
 func GetCommits(name string, branch string) Commits {
 	url := "https://api.github.com/repos/" + name + "/commits"
 
+	// Without a branch we get commits on the default branch
+	if branch != "" {
+		url += "?sha=" + neturl.QueryEscape(branch)
+	}
+
 	headers := make(map[string]string)
 	headers["Accept"] = "application/vnd.github.v3+json"
-	headers["Sha"] = branch
-	response := utils.GetRequest(url, headers)
+	response := utils.GetCachedRequest(url, headers, CacheTTL)
 
 	commits := Commits{}
 	err := json.Unmarshal([]byte(response), &commits)
@@ -81,7 +81,7 @@ type Commits []struct {
 	SHA         string `json:"sha"`
 	NodeID      string `json:"node_id"`
 	HTMLURL     string `json:"html_url"`
-	CommentsURL string `json:"comments_url`
+	CommentsURL string `json:"comments_url"`
 	Commit      struct {
 		URL    string `json:"url"`
 		Author struct {
@@ -105,7 +105,7 @@ type Commits []struct {
 			Reason    string `json:"reason"`
 			Signature string `json:"signature"`
 			Payload   string `json:"payload"`
-		} `json:"verification`
+		} `json:"verification"`
 	} `json:"commit"`
 	Author struct {
 		Login             string `json:"login"`
@@ -0,0 +1,67 @@
+package goproxy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vsoch/uptodate/parsers"
+)
+
+// Updater updates a go module version build arg
+// # uptodate: go <module or package path>
+// ARG TOOL_VERSION=<version>
+type Updater struct{}
+
+func init() {
+	parsers.RegisterUpdater(&Updater{})
+}
+
+// Name of the updater, for directives
+func (u *Updater) Name() string {
+	return "go"
+}
+
+// Match never matches a name, a module path can't be written in a build arg name.
+// It comes from a directive, which can leave it to a go install of the build arg in a RUN.
+func (u *Updater) Match(name string) bool {
+	return false
+}
+
+// Latest returns the newest version of the module (matching a filter, if given) with the same
+// major version. Pre-releases and pseudo-versions are only considered if the current value is one.
+func (u *Updater) Latest(name string, value string, args []string, filter string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("the go source needs a module (or package) path")
+	}
+	modulePath, err := FindModule(args[0])
+	if err != nil {
+		return "", err
+	}
+	versions, err := GetVersions(modulePath, OptionsFor(value))
+	if err != nil {
+		return "", err
+	}
+	return parsers.NewestMatch(versions, filter)
+}
+
+// ListVersions returns versions of a module for a Docker Build arg. Set pseudo or
+// prerelease to true in the params to include pseudo-versions or pre-releases.
+func (u *Updater) ListVersions(name string, params map[string]string) ([]string, error) {
+	options := Options{
+		Pseudo:      params["pseudo"] == "true",
+		PreReleases: params["prerelease"] == "true",
+	}
+	return GetVersions(name, options)
+}
+
+// Created returns when each version was created. Each has its own info, so only
+// the versions asked for are looked up.
+func (u *Updater) Created(name string, params map[string]string, versions []string) (map[string]time.Time, error) {
+	created := map[string]time.Time{}
+	for _, version := range versions {
+		if info, err := VersionInfo(name, version); err == nil {
+			created[version] = info.Time
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,149 @@
+package goproxy
+
+// The goproxy parser looks up module versions with the GOPROXY protocol
+// (https://go.dev/ref/mod#goproxy-protocol), e.g., <proxy>/<module>/@v/list
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+// ProxyURL is used instead of GOPROXY if set, e.g., from the repository config
+var ProxyURL = ""
+
+// DefaultProxy is used when GOPROXY isn't set
+var DefaultProxy = "https://proxy.golang.org,direct"
+
+// CacheTTL is how long version lists are cached
+var CacheTTL = time.Hour
+
+// fileClient reads from a proxy on the filesystem, e.g., GOPROXY=file:///tmp/proxy
+var fileClient = &http.Client{Transport: http.NewFileTransport(http.Dir("/"))}
+
+// pseudoVersionRegex matches a pseudo-version, e.g., v0.0.0-20191109021931-daa7c04131f5
+var pseudoVersionRegex = regexp.MustCompile(`^v[0-9]+\.(0\.0-|\d+\.\d+-([^+]*\.)?0\.)\d{14}-[A-Za-z0-9]+(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+
+// Info is the response for <module>/@latest (and <module>/@v/<version>.info)
+type Info struct {
+	Version string    `json:"Version"`
+	Time    time.Time `json:"Time"`
+}
+
+// proxy is one entry of GOPROXY, and if we try the next for any error (|) or only not found (,)
+type proxy struct {
+	URL             string
+	FallbackOnError bool
+}
+
+// IsPseudoVersion determines if a version is a pseudo-version for a commit
+func IsPseudoVersion(version string) bool {
+	return pseudoVersionRegex.MatchString(version)
+}
+
+// proxies parses GOPROXY (or ProxyURL) into the list of proxies to try
+func proxies() []proxy {
+	value := ProxyURL
+	if value == "" {
+		value = os.Getenv("GOPROXY")
+	}
+	if value == "" {
+		value = DefaultProxy
+	}
+	list := []proxy{}
+	for value != "" {
+		end := strings.IndexAny(value, ",|")
+		entry := value
+		fallbackOnError := false
+		if end >= 0 {
+			entry = value[:end]
+			fallbackOnError = value[end] == '|'
+			value = value[end+1:]
+		} else {
+			value = ""
+		}
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, proxy{URL: strings.TrimRight(entry, "/"), FallbackOnError: fallbackOnError})
+		}
+	}
+	return list
+}
+
+// EscapePath escapes a module path for a proxy, where an uppercase letter is ! and the lowercase letter
+func EscapePath(path string) string {
+	escaped := ""
+	for _, r := range path {
+		if r >= 'A' && r <= 'Z' {
+			escaped += "!" + string(r+'a'-'A')
+		} else {
+			escaped += string(r)
+		}
+	}
+	return escaped
+}
+
+// errNotFound is returned when no proxy has a module
+type errNotFound struct {
+	path string
+}
+
+func (e *errNotFound) Error() string {
+	return e.path + " was not found"
+}
+
+// get fetches a path from each proxy in turn, as the go command does
+func get(path string) ([]byte, error) {
+	var lastErr error = &errNotFound{path: path}
+	for _, p := range proxies() {
+		if p.URL == "off" {
+			return nil, fmt.Errorf("cannot look up %s, GOPROXY is off", path)
+		}
+		if p.URL == "direct" {
+			return nil, fmt.Errorf("cannot look up %s directly from version control, only through a proxy", path)
+		}
+
+		var response *utils.Response
+		var err error
+		url := p.URL + "/" + path
+		if strings.HasPrefix(url, "file://") {
+			response, err = getFile(url)
+		} else {
+			response, err = utils.GetCachedResponse(url, map[string]string{}, CacheTTL)
+		}
+
+		switch {
+		case err == nil && response.StatusCode == http.StatusOK:
+			return response.Body, nil
+		case err == nil && (response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusGone):
+			lastErr = &errNotFound{path: path}
+			continue
+		case err == nil:
+			err = fmt.Errorf("%s returned status %d", url, response.StatusCode)
+		}
+		if !p.FallbackOnError {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// getFile reads from a proxy on the filesystem, which we don't cache
+func getFile(url string) (*utils.Response, error) {
+	raw, err := fileClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer raw.Body.Close()
+	body, err := ioutil.ReadAll(raw.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &utils.Response{StatusCode: raw.StatusCode, Header: raw.Header, Body: body}, nil
+}
@@ -0,0 +1,244 @@
+package goproxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+func TestProxies(t *testing.T) {
+	defer func() { ProxyURL = "" }()
+	tests := []struct {
+		value string
+		want  []proxy
+	}{
+		{"https://proxy.golang.org,direct", []proxy{{"https://proxy.golang.org", false}, {"direct", false}}},
+		{"https://a.example.com|https://b.example.com/,off", []proxy{{"https://a.example.com", true}, {"https://b.example.com", false}, {"off", false}}},
+		{" https://a.example.com , ,file:///srv/goproxy/ ", []proxy{{"https://a.example.com", false}, {"file:///srv/goproxy", false}}},
+		{"off", []proxy{{"off", false}}},
+	}
+	for _, tt := range tests {
+		ProxyURL = tt.value
+		if got := proxies(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("proxies() for %q = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	// Without a config, GOPROXY is used, and then the default
+	ProxyURL = ""
+	goproxy, set := os.LookupEnv("GOPROXY")
+	defer func() {
+		if set {
+			os.Setenv("GOPROXY", goproxy)
+		} else {
+			os.Unsetenv("GOPROXY")
+		}
+	}()
+	os.Setenv("GOPROXY", "https://env.example.com")
+	if got := proxies(); !reflect.DeepEqual(got, []proxy{{"https://env.example.com", false}}) {
+		t.Errorf("proxies() from GOPROXY = %v", got)
+	}
+	os.Unsetenv("GOPROXY")
+	if got := proxies(); !reflect.DeepEqual(got, []proxy{{"https://proxy.golang.org", false}, {"direct", false}}) {
+		t.Errorf("proxies() by default = %v", got)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	tests := map[string]string{
+		"example.com/tool":              "example.com/tool",
+		"github.com/BurntSushi/toml":    "github.com/!burnt!sushi/toml",
+		"github.com/Azure/azure-sdk/v2": "github.com/!azure/azure-sdk/v2",
+		"v1.0.0-RC1":                    "v1.0.0-!r!c1",
+	}
+	for path, want := range tests {
+		if got := EscapePath(path); got != want {
+			t.Errorf("EscapePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestMajorVersion(t *testing.T) {
+	tests := map[string]string{
+		"example.com/tool":     "",
+		"example.com/tool/v1":  "",
+		"example.com/tool/v2":  "v2",
+		"example.com/tool/v10": "v10",
+		"example.com/v2/tool":  "",
+		"gopkg.in/yaml.v3":     "v3",
+		"gopkg.in/yaml.v1":     "v1",
+	}
+	for path, want := range tests {
+		if got := MajorVersion(path); got != want {
+			t.Errorf("MajorVersion(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestOptionsFor(t *testing.T) {
+	tests := []struct {
+		current string
+		want    Options
+	}{
+		{"v1.2.0", Options{}},
+		{"v1.2.0-rc.1", Options{PreReleases: true}},
+		{"v0.0.0-20191109021931-daa7c04131f5", Options{Pseudo: true}},
+		{"v1.2.4-0.20191109021931-daa7c04131f5", Options{Pseudo: true}},
+		{"v2.0.0+incompatible", Options{Incompatible: true}},
+	}
+	for _, tt := range tests {
+		if got := OptionsFor(tt.current); got != tt.want {
+			t.Errorf("OptionsFor(%q) = %+v, want %+v", tt.current, got, tt.want)
+		}
+	}
+}
+
+// newTestProxy serves example.com/tool, and fails for example.com/broken
+func newTestProxy() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/tool/@v/list":
+			w.Write([]byte("v1.10.0\nv1.2.0\nv1.11.0-rc.1\nv2.0.0+incompatible\nv1.9.0\nv2.1.0\n"))
+		case "/example.com/tool/@latest":
+			w.Write([]byte(`{"Version": "v1.10.0", "Time": "2024-01-01T00:00:00Z"}`))
+		case "/example.com/broken/@v/list":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGetVersions(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false; ProxyURL = "" }()
+
+	server := newTestProxy()
+	defer server.Close()
+	empty := httptest.NewServer(http.NotFoundHandler())
+	defer empty.Close()
+
+	tests := []struct {
+		name    string
+		proxy   string
+		module  string
+		options Options
+		want    []string
+		wantErr bool
+	}{
+		{"releases", server.URL, "example.com/tool", Options{}, []string{"v1.2.0", "v1.9.0", "v1.10.0"}, false},
+		{"pre-releases", server.URL, "example.com/tool", Options{PreReleases: true}, []string{"v1.2.0", "v1.9.0", "v1.10.0", "v1.11.0-rc.1"}, false},
+		{"incompatible", server.URL, "example.com/tool", Options{Incompatible: true}, []string{"v1.2.0", "v1.9.0", "v1.10.0", "v2.0.0+incompatible"}, false},
+		{"next proxy when not found", empty.URL + "," + server.URL, "example.com/tool", Options{}, []string{"v1.2.0", "v1.9.0", "v1.10.0"}, false},
+		{"stop on an error", server.URL + "," + empty.URL, "example.com/broken", Options{}, nil, true},
+		{"off", "off", "example.com/tool", Options{}, nil, true},
+		{"direct", "direct", "example.com/tool", Options{}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ProxyURL = tt.proxy
+			got, err := GetVersions(tt.module, tt.options)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GetVersions(%q) = %v, want an error", tt.module, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetVersions(%q) returned an error: %s", tt.module, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetVersions(%q) = %v, want %v", tt.module, got, tt.want)
+			}
+		})
+	}
+
+	// A broken proxy followed by | falls back, and the error is not found from the last
+	ProxyURL = server.URL + "|" + empty.URL
+	if _, err := GetVersions("example.com/broken", Options{}); err == nil {
+		t.Errorf("GetVersions for a missing module did not return an error")
+	} else if _, notFound := err.(*errNotFound); !notFound {
+		t.Errorf("GetVersions after falling back returned %v, want not found", err)
+	}
+}
+
+func TestFindModule(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false; ProxyURL = "" }()
+
+	server := newTestProxy()
+	defer server.Close()
+	ProxyURL = server.URL
+
+	for _, path := range []string{"example.com/tool", "example.com/tool/cmd/tool"} {
+		got, err := FindModule(path)
+		if err != nil {
+			t.Errorf("FindModule(%q) returned an error: %s", path, err)
+			continue
+		}
+		if got != "example.com/tool" {
+			t.Errorf("FindModule(%q) = %q, want example.com/tool", path, got)
+		}
+	}
+	if got, err := FindModule("example.com/other/cmd"); err == nil {
+		t.Errorf("FindModule for a missing module = %q, want an error", got)
+	}
+}
+
+func TestFileProxy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A module with uppercase letters is escaped on disk, as the go command writes it
+	files := map[string]string{
+		"example.com/!my!tool/@v/list":         "v1.2.0\nv1.10.0\nv1.3.0-beta.1\n",
+		"example.com/!my!tool/@v/v1.10.0.info": `{"Version": "v1.10.0", "Time": "2024-02-01T00:00:00Z"}`,
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goproxy := os.Getenv("GOPROXY")
+	defer os.Setenv("GOPROXY", goproxy)
+	os.Setenv("GOPROXY", "file://"+filepath.ToSlash(dir))
+
+	updater := Updater{}
+	versions, err := updater.ListVersions("example.com/MyTool", map[string]string{})
+	if err != nil {
+		t.Fatalf("ListVersions() returned an error: %s", err)
+	}
+	if !reflect.DeepEqual(versions, []string{"v1.2.0", "v1.10.0"}) {
+		t.Errorf("ListVersions() = %v, want [v1.2.0 v1.10.0]", versions)
+	}
+
+	// Only versions with an info file have a creation time
+	created, err := updater.Created("example.com/MyTool", map[string]string{}, versions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created["v1.10.0"].Format("2006-01-02") != "2024-02-01" {
+		t.Errorf("Created() = %v, want only v1.10.0 at 2024-02-01", created)
+	}
+
+	// A module that isn't in the directory is not found
+	if _, err := GetVersions("example.com/other", Options{}); err == nil {
+		t.Errorf("GetVersions for a missing module did not return an error")
+	} else if _, notFound := err.(*errNotFound); !notFound {
+		t.Errorf("GetVersions for a missing module returned %v, want not found", err)
+	}
+}
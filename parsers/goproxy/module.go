@@ -0,0 +1,137 @@
+package goproxy
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// majorSuffixRegex matches the major version at the end of a module path, e.g., /v2 or gopkg.in/yaml.v3
+var majorSuffixRegex = regexp.MustCompile(`(?:/|^gopkg\.in/.*\.)(v[0-9]+)$`)
+
+// Options decide which versions (besides releases) can be selected
+type Options struct {
+	PreReleases  bool // e.g., v1.2.0-rc.1
+	Pseudo       bool // e.g., v0.0.0-20191109021931-daa7c04131f5, for a commit
+	Incompatible bool // e.g., v2.0.0+incompatible, a v2 without a go.mod
+}
+
+// OptionsFor allows the kinds of versions the current value is, so a pre-release
+// can move to a newer pre-release (or release)
+func OptionsFor(current string) Options {
+	return Options{
+		Pseudo:       IsPseudoVersion(current),
+		PreReleases:  !IsPseudoVersion(current) && strings.Contains(strings.SplitN(current, "+", 2)[0], "-"),
+		Incompatible: strings.HasSuffix(current, "+incompatible"),
+	}
+}
+
+// MajorVersion returns the major version a module path requires, e.g., example.com/tool/v2
+// is v2, and an empty string (for v0 or v1) if there is no suffix
+func MajorVersion(modulePath string) string {
+	match := majorSuffixRegex.FindStringSubmatch(modulePath)
+	if match == nil || (match[1] == "v0" || match[1] == "v1") && !strings.HasPrefix(modulePath, "gopkg.in/") {
+		return ""
+	}
+	return match[1]
+}
+
+// matchesMajor determines if a version can belong to a module path, given its major version suffix
+func matchesMajor(modulePath string, version string) bool {
+	major := strings.SplitN(version, ".", 2)[0]
+	suffix := MajorVersion(modulePath)
+	if suffix == "" {
+		return major == "v0" || major == "v1" || strings.HasSuffix(version, "+incompatible")
+	}
+	return major == suffix
+}
+
+// Latest returns the response for <module>/@latest
+func Latest(modulePath string) (Info, error) {
+	info := Info{}
+	body, err := get(EscapePath(modulePath) + "/@latest")
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(body, &info)
+	return info, err
+}
+
+// VersionInfo returns the response for <module>/@v/<version>.info
+func VersionInfo(modulePath string, version string) (Info, error) {
+	info := Info{}
+	body, err := get(EscapePath(modulePath) + "/@v/" + EscapePath(version) + ".info")
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(body, &info)
+	return info, err
+}
+
+// FindModule finds the module that provides a package path (e.g., example.com/tool/cmd/tool),
+// trying the longest path first as the go command does
+func FindModule(packagePath string) (string, error) {
+	modulePath := packagePath
+	for {
+		_, err := Latest(modulePath)
+		if err == nil {
+			return modulePath, nil
+		}
+		if _, notFound := err.(*errNotFound); !notFound || !strings.Contains(modulePath, "/") {
+			return "", err
+		}
+		modulePath = path.Dir(modulePath)
+	}
+}
+
+// GetVersions returns the versions of a module from oldest to newest. Pre-releases,
+// pseudo-versions, and incompatible versions are only included if the options allow.
+func GetVersions(modulePath string, options Options) ([]string, error) {
+	body, err := get(EscapePath(modulePath) + "/@v/list")
+	if err != nil {
+		return nil, err
+	}
+	contenders := strings.Fields(string(body))
+
+	// A module without tagged versions only has a pseudo-version for the latest commit
+	if options.Pseudo {
+		if info, err := Latest(modulePath); err == nil && IsPseudoVersion(info.Version) {
+			contenders = append(contenders, info.Version)
+		}
+	}
+
+	found := []semver.Version{}
+	original := map[string]string{}
+	for _, version := range contenders {
+		if !matchesMajor(modulePath, version) {
+			continue
+		}
+		if IsPseudoVersion(version) && !options.Pseudo {
+			continue
+		}
+		if strings.HasSuffix(version, "+incompatible") && !options.Incompatible {
+			continue
+		}
+		v, err := semver.Parse(strings.TrimPrefix(version, "v"))
+		if err != nil {
+			continue
+		}
+		if len(v.Pre) > 0 && !IsPseudoVersion(version) && !options.PreReleases {
+			continue
+		}
+		if _, ok := original[v.String()]; !ok {
+			found = append(found, v)
+		}
+		original[v.String()] = version
+	}
+	semver.Sort(found)
+
+	versions := []string{}
+	for _, v := range found {
+		versions = append(versions, original[v.String()])
+	}
+	return versions, nil
+}
@@ -0,0 +1,87 @@
+package npm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vsoch/uptodate/parsers"
+)
+
+// Updater updates an npm version build arg
+// ARG uptodate_npm_<package>=<version>
+// ARG uptodate_npm_<scope>__<package>=<version>
+// # uptodate: npm <package> [dist-tag or range]
+type Updater struct{}
+
+func init() {
+	parsers.RegisterUpdater(&Updater{})
+}
+
+// Name of the updater, for directives
+func (u *Updater) Name() string {
+	return "npm"
+}
+
+// Match a build arg named with the npm prefix
+func (u *Updater) Match(name string) bool {
+	return strings.HasPrefix(name, "uptodate_npm")
+}
+
+// Latest returns the version a dist-tag points to, or the newest in a range. Without either,
+// it follows the latest dist-tag (or looks at every version, if there is a filter).
+func (u *Updater) Latest(name string, value string, args []string, filter string) (string, error) {
+	var pkgName, selector string
+	if len(args) > 0 {
+		pkgName = args[0]
+		selector = strings.Join(args[1:], " ")
+	} else {
+		fmt.Printf("Found npm build arg prefix %s\n", name)
+		pkgName = strings.Replace(name, "uptodate_npm_", "", 1)
+
+		// A scope is separated from the package by __
+		if strings.Contains(pkgName, "__") {
+			scopeName := strings.SplitN(pkgName, "__", 2)
+			if scopeName[0] == "" || scopeName[1] == "" {
+				return "", fmt.Errorf("scope (%s) or package (%s) is empty, cannot parse", scopeName[0], scopeName[1])
+			}
+			pkgName = "@" + scopeName[0] + "/" + scopeName[1]
+		}
+	}
+	if selector == "" && filter == "" {
+		selector = "latest"
+	}
+
+	pkg, err := GetPackage(pkgName, "")
+	if err != nil {
+		return "", err
+	}
+	versions, err := pkg.Select(selector)
+	if err != nil {
+		return "", err
+	}
+	return parsers.NewestMatch(versions, filter)
+}
+
+// ListVersions returns versions of a package for a Docker Build arg. The params can
+// set a registry, and a tag (e.g., next) or range (e.g., ^5.1.0) to select versions.
+func (u *Updater) ListVersions(name string, params map[string]string) ([]string, error) {
+	pkg, err := GetPackage(name, params["registry"])
+	if err != nil {
+		return nil, err
+	}
+	selector := params["range"]
+	if tag := params["tag"]; tag != "" {
+		selector = tag
+	}
+	return pkg.Select(selector)
+}
+
+// Created returns when each version was published
+func (u *Updater) Created(name string, params map[string]string, versions []string) (map[string]time.Time, error) {
+	pkg, err := GetPackage(name, params["registry"])
+	if err != nil {
+		return nil, err
+	}
+	return pkg.Published(), nil
+}
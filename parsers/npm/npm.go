@@ -0,0 +1,105 @@
+package npm
+
+// The npm parser reads package metadata (a packument) from an npm registry, e.g.,
+// https://registry.npmjs.org/<package>
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/vsoch/uptodate/utils"
+)
+
+// RegistryURL is the npm registry, and can be set to a mirror (or a local stand-in)
+var RegistryURL = "https://registry.npmjs.org"
+
+// CacheTTL is how long package metadata is cached
+var CacheTTL = time.Hour
+
+// A Package matches the format of <registry>/<package>
+type Package struct {
+	Name     string                    `json:"name"`
+	DistTags map[string]string         `json:"dist-tags"`
+	Versions map[string]PackageVersion `json:"versions"`
+	Time     map[string]string         `json:"time"`
+}
+
+// PackageVersion is the metadata for one version of a package
+type PackageVersion struct {
+	Version    string `json:"version"`
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+// GetPackage gets the metadata for a package from a registry (the default if empty).
+// A scoped package (@scope/name) is escaped as the registry expects.
+func GetPackage(name string, registry string) (Package, error) {
+	pkg := Package{}
+	if registry == "" {
+		registry = RegistryURL
+	}
+	url := strings.TrimRight(registry, "/") + "/" + strings.Replace(name, "/", "%2f", 1)
+	response, err := utils.GetCachedResponse(url, map[string]string{"Accept": "application/json"}, CacheTTL)
+	if err != nil {
+		return pkg, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return pkg, fmt.Errorf("%s returned status %d", url, response.StatusCode)
+	}
+	if err := json.Unmarshal(response.Body, &pkg); err != nil {
+		return pkg, fmt.Errorf("issue unmarshalling %s: %s", url, err)
+	}
+	return pkg, nil
+}
+
+// Published returns when each version was published, if the registry says
+func (p *Package) Published() map[string]time.Time {
+	published := map[string]time.Time{}
+	for version, when := range p.Time {
+		if created, err := time.Parse(time.RFC3339, when); err == nil {
+			published[version] = created
+		}
+	}
+	return published
+}
+
+// GetVersions returns versions from oldest to newest, skipping those that are deprecated
+// and pre-releases. If a range (e.g., ^5.1.0) is given, versions must be in it.
+func (p *Package) GetVersions(versionRange string) ([]string, error) {
+	inRange := func(semver.Version) bool { return true }
+	if versionRange != "" {
+		parsed, err := ParseRange(versionRange)
+		if err != nil {
+			return nil, err
+		}
+		inRange = parsed
+	}
+
+	found := []semver.Version{}
+	for name, version := range p.Versions {
+		v, err := semver.Parse(name)
+		if err != nil || len(v.Pre) > 0 || version.Deprecated != "" || !inRange(v) {
+			continue
+		}
+		found = append(found, v)
+	}
+	semver.Sort(found)
+
+	versions := []string{}
+	for _, v := range found {
+		versions = append(versions, v.String())
+	}
+	return versions, nil
+}
+
+// Select returns the version a dist-tag (e.g., latest or next) points to if the
+// selector is one, and otherwise the versions in the range, from oldest to newest
+func (p *Package) Select(selector string) ([]string, error) {
+	if version, ok := p.DistTags[selector]; ok {
+		return []string{version}, nil
+	}
+	return p.GetVersions(selector)
+}
@@ -0,0 +1,60 @@
+package npm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+func TestListVersions(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	// A scoped package is escaped in the url
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/@types%2fnode" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"name": "@types/node", "dist-tags": {"latest": "20.1.0", "next": "21.0.0-rc.1"},
+			"versions": {"18.0.0": {"version": "18.0.0"}, "20.1.0": {"version": "20.1.0"},
+			             "20.0.0": {"version": "20.0.0", "deprecated": "broken"}, "21.0.0-rc.1": {"version": "21.0.0-rc.1"}},
+			"time": {"created": "2016-05-17T18:34:59.000Z", "18.0.0": "2022-04-20T00:00:00.000Z", "20.1.0": "2023-05-01T00:00:00.000Z"}}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		params map[string]string
+		want   []string
+	}{
+		{map[string]string{"registry": server.URL}, []string{"18.0.0", "20.1.0"}},
+		{map[string]string{"registry": server.URL, "range": "^20.0.0"}, []string{"20.1.0"}},
+		{map[string]string{"registry": server.URL, "tag": "next", "range": "^20.0.0"}, []string{"21.0.0-rc.1"}},
+	}
+	updater := Updater{}
+	for _, tt := range tests {
+		got, err := updater.ListVersions("@types/node", tt.params)
+		if err != nil {
+			t.Fatalf("ListVersions(%v) returned an error: %s", tt.params, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ListVersions(%v) = %v, want %v", tt.params, got, tt.want)
+		}
+	}
+
+	// Versions without a time (20.0.0 here) are left out
+	created, err := updater.Created("@types/node", map[string]string{"registry": server.URL}, []string{"18.0.0", "20.1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := created["20.0.0"]; ok || created["20.1.0"].Format("2006-01-02") != "2023-05-01" {
+		t.Errorf("Created() = %v, want 20.1.0 published 2023-05-01", created)
+	}
+
+	if _, err := updater.ListVersions("missing", map[string]string{"registry": server.URL}); err == nil {
+		t.Errorf("ListVersions() for a missing package did not return an error")
+	}
+}
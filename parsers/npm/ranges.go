@@ -0,0 +1,186 @@
+package npm
+
+// Ranges are written as npm does (https://github.com/npm/node-semver#ranges), e.g.,
+// ^5.1.0, ~5.1, 5.x, >=4.2 <6 || 7.0.0, or 1.2.3 - 2.3. They are expanded into
+// comparators with full versions for github.com/blang/semver.
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// hyphenRange matches an inclusive range, e.g., 1.2.3 - 2.3.4
+var hyphenRange = regexp.MustCompile(`^(\S+)\s+-\s+(\S+)$`)
+
+// comparatorRegex splits a comparator into an operator and a (partial) version
+var comparatorRegex = regexp.MustCompile(`^(\^|~>?|>=|<=|>|<|=)?\s*v?(.*)$`)
+
+// partial is a version with missing (or wildcard) parts set to -1
+type partial struct {
+	major, minor, patch int
+	pre                 string
+}
+
+// parsePartial parses a version like 1, 1.2, 1.x, or 1.2.3-beta.1
+func parsePartial(version string) (partial, error) {
+	p := partial{major: -1, minor: -1, patch: -1}
+	version = strings.SplitN(version, "+", 2)[0]
+	if parts := strings.SplitN(version, "-", 2); len(parts) == 2 {
+		version, p.pre = parts[0], parts[1]
+	}
+	numbers := []*int{&p.major, &p.minor, &p.patch}
+	for i, part := range strings.Split(version, ".") {
+		if i >= len(numbers) {
+			return p, fmt.Errorf("%s has too many parts", version)
+		}
+		if part == "x" || part == "X" || part == "*" || part == "" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return p, fmt.Errorf("%s is not a version", version)
+		}
+		*numbers[i] = n
+	}
+	if p.pre != "" && p.patch < 0 {
+		return p, fmt.Errorf("%s has a pre-release without a full version", version)
+	}
+	return p, nil
+}
+
+// floor is the lowest version a partial matches, e.g., 1.2 is 1.2.0
+func (p partial) floor() string {
+	version := fmt.Sprintf("%d.%d.%d", zero(p.major), zero(p.minor), zero(p.patch))
+	if p.pre != "" {
+		version += "-" + p.pre
+	}
+	return version
+}
+
+// next is the first version after those a partial matches, e.g., 1.2 is 1.3.0
+func (p partial) next() string {
+	if p.minor < 0 {
+		return fmt.Sprintf("%d.0.0", p.major+1)
+	}
+	return fmt.Sprintf("%d.%d.0", p.major, p.minor+1)
+}
+
+// zero returns a missing part as 0
+func zero(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// expandComparator expands one comparator into comparators with full versions
+func expandComparator(comparator string) ([]string, error) {
+	match := comparatorRegex.FindStringSubmatch(comparator)
+	op, version := match[1], match[2]
+	p, err := parsePartial(version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Anything, e.g., * or x
+	if p.major < 0 {
+		if op == "<" || op == ">" {
+			return []string{"<0.0.0"}, nil
+		}
+		return []string{">=0.0.0"}, nil
+	}
+	isPartial := p.patch < 0
+
+	switch op {
+	case "^":
+		// The left-most non-zero part is the one that can't change
+		upper := fmt.Sprintf("%d.0.0", p.major+1)
+		if p.major == 0 && p.minor >= 0 {
+			upper = fmt.Sprintf("0.%d.0", p.minor+1)
+			if p.minor == 0 && p.patch >= 0 {
+				upper = fmt.Sprintf("0.0.%d", p.patch+1)
+			}
+		}
+		return []string{">=" + p.floor(), "<" + upper}, nil
+	case "~", "~>":
+		return []string{">=" + p.floor(), "<" + partial{major: p.major, minor: p.minor}.next()}, nil
+	case ">":
+		if isPartial {
+			return []string{">=" + p.next()}, nil
+		}
+		return []string{">" + p.floor()}, nil
+	case "<=":
+		if isPartial {
+			return []string{"<" + p.next()}, nil
+		}
+		return []string{"<=" + p.floor()}, nil
+	case ">=", "<":
+		return []string{op + p.floor()}, nil
+	}
+
+	// An exact version, or an x-range like 1.2.x
+	if isPartial {
+		return []string{">=" + p.floor(), "<" + p.next()}, nil
+	}
+	return []string{"=" + p.floor()}, nil
+}
+
+// ParseRange parses an npm range into a range we can check versions against
+func ParseRange(expr string) (semver.Range, error) {
+	var result semver.Range
+	for _, set := range strings.Split(expr, "||") {
+		set = strings.TrimSpace(set)
+		comparators := []string{}
+
+		if match := hyphenRange.FindStringSubmatch(set); match != nil {
+			lower, err := parsePartial(strings.TrimPrefix(match[1], "v"))
+			if err != nil {
+				return nil, err
+			}
+			upper, err := parsePartial(strings.TrimPrefix(match[2], "v"))
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, ">="+lower.floor())
+			if upper.major >= 0 && upper.patch < 0 {
+				comparators = append(comparators, "<"+upper.next())
+			} else if upper.major >= 0 {
+				comparators = append(comparators, "<="+upper.floor())
+			}
+		} else {
+
+			// Operators can be separated from their versions, e.g., >= 1.2
+			fields := strings.Fields(set)
+			for i := 0; i < len(fields); i++ {
+				field := fields[i]
+				if strings.Trim(field, "^~<>=") == "" && i+1 < len(fields) {
+					field += fields[i+1]
+					i++
+				}
+				expanded, err := expandComparator(field)
+				if err != nil {
+					return nil, err
+				}
+				comparators = append(comparators, expanded...)
+			}
+			if len(comparators) == 0 {
+				comparators = append(comparators, ">=0.0.0")
+			}
+		}
+
+		setRange, err := semver.ParseRange(strings.Join(comparators, " "))
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid range: %s", expr, err)
+		}
+		if result == nil {
+			result = setRange
+		} else {
+			result = result.OR(setRange)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package npm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blang/semver/v4"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		expr string
+		in   []string
+		out  []string
+	}{
+		{"^5.1.0", []string{"5.1.0", "5.9.9"}, []string{"5.0.9", "6.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.2.2", "0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^0.0", []string{"0.0.0", "0.0.9"}, []string{"0.1.0"}},
+		{"~5.1", []string{"5.1.0", "5.1.7"}, []string{"5.0.9", "5.2.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.2.2", "1.3.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"5.x", []string{"5.0.0", "5.9.0"}, []string{"4.9.9", "6.0.0"}},
+		{"1.2.*", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"*", []string{"0.0.1", "9.9.9"}, nil},
+		{"", []string{"0.0.1", "9.9.9"}, nil},
+		{"1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"v1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{">= 4.2 < 6", []string{"4.2.0", "5.9.9"}, []string{"4.1.9", "6.0.0"}},
+		{">=4.2 <6 || 7.0.0", []string{"5.0.0", "7.0.0"}, []string{"6.0.0", "7.0.1"}},
+		{"1.2.3 - 2.3", []string{"1.2.3", "2.3.9"}, []string{"1.2.2", "2.4.0"}},
+		{"1.2 - 2.3.4", []string{"1.2.0", "2.3.4"}, []string{"1.1.9", "2.3.5"}},
+	}
+	for _, tt := range tests {
+		inRange, err := ParseRange(tt.expr)
+		if err != nil {
+			t.Errorf("ParseRange(%q) returned an error: %s", tt.expr, err)
+			continue
+		}
+		for _, version := range tt.in {
+			if !inRange(semver.MustParse(version)) {
+				t.Errorf("%s should be in %q", version, tt.expr)
+			}
+		}
+		for _, version := range tt.out {
+			if inRange(semver.MustParse(version)) {
+				t.Errorf("%s should not be in %q", version, tt.expr)
+			}
+		}
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	for _, expr := range []string{"^latest", "1.2.3.4", "1.2-beta", ">=a.b"} {
+		if _, err := ParseRange(expr); err == nil {
+			t.Errorf("ParseRange(%q) did not return an error", expr)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	pkg := Package{
+		DistTags: map[string]string{"latest": "5.2.0", "next": "6.0.0-rc.1"},
+		Versions: map[string]PackageVersion{
+			"4.9.0":      {Version: "4.9.0"},
+			"5.10.0":     {Version: "5.10.0", Deprecated: "use 5.11.0"},
+			"5.2.0":      {Version: "5.2.0"},
+			"5.11.0":     {Version: "5.11.0"},
+			"6.0.0-rc.1": {Version: "6.0.0-rc.1"},
+		},
+	}
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"latest", []string{"5.2.0"}},
+		{"next", []string{"6.0.0-rc.1"}},
+		{"^5.0.0", []string{"5.2.0", "5.11.0"}},
+		{"", []string{"4.9.0", "5.2.0", "5.11.0"}},
+	}
+	for _, tt := range tests {
+		got, err := pkg.Select(tt.selector)
+		if err != nil {
+			t.Errorf("Select(%q) returned an error: %s", tt.selector, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Select(%q) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
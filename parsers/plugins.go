@@ -0,0 +1,194 @@
+package parsers
+
+// Plugins are executables on the PATH named uptodate-source-<name>. They are
+// given a PluginRequest as json on stdin, and write a PluginResponse as json
+// to stdout, with versions ordered newest first.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PluginPrefix starts the name of a plugin executable
+var PluginPrefix = "uptodate-source-"
+
+// PluginTimeout is how long a plugin can run before it is stopped
+var PluginTimeout = time.Minute
+
+// PluginRequest is sent to a plugin on stdin
+type PluginRequest struct {
+	Name   string            `json:"name"`
+	Value  string            `json:"value,omitempty"`
+	Args   []string          `json:"args,omitempty"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// PluginVersion is one version found by a plugin
+type PluginVersion struct {
+	Version  string            `json:"version"`
+	Created  time.Time         `json:"created,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// PluginResponse is read from a plugin on stdout
+type PluginResponse struct {
+	Versions []PluginVersion `json:"versions"`
+}
+
+// A Plugin is an external executable that finds versions
+type Plugin struct {
+	Source string
+	Path   string
+
+	// found are the versions for each request, so a plugin is run once per request
+	found map[string][]PluginVersion
+}
+
+// discovered makes sure we only look for plugins once
+var discovered sync.Once
+
+// FindPlugin looks for the executable for a source on the PATH
+func FindPlugin(source string) (*Plugin, bool) {
+	if source == "" || strings.ContainsAny(source, "/\\") {
+		return nil, false
+	}
+	path, err := exec.LookPath(PluginPrefix + source)
+	if err != nil {
+		return nil, false
+	}
+	return &Plugin{Source: source, Path: path}, true
+}
+
+// discoverPlugins registers plugins on the PATH, without replacing updaters
+// that are already registered (or plugins earlier on the PATH)
+func discoverPlugins() {
+	discovered.Do(func() {
+		for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+			files, err := ioutil.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, file := range files {
+				source := strings.TrimPrefix(file.Name(), PluginPrefix)
+				if source == file.Name() || source == "" || file.IsDir() || file.Mode()&0111 == 0 {
+					continue
+				}
+				if _, ok := registeredUpdater(source); ok {
+					continue
+				}
+				RegisterUpdater(&Plugin{Source: source, Path: filepath.Join(dir, file.Name())})
+			}
+		}
+	})
+}
+
+// Name of the plugin source, for directives and build arg types
+func (p *Plugin) Name() string {
+	return p.Source
+}
+
+// Match a build arg named uptodate_<source>_, with dashes in the source as underscores
+func (p *Plugin) Match(name string) bool {
+	return strings.HasPrefix(name, "uptodate_"+strings.Replace(p.Source, "-", "_", -1)+"_")
+}
+
+// Latest returns the newest version from the plugin (matching a filter, if given)
+func (p *Plugin) Latest(name string, value string, args []string, filter string) (string, error) {
+	found, err := p.Versions(PluginRequest{Name: name, Value: value, Args: args})
+	if err != nil {
+		return "", err
+	}
+	versions := []string{}
+	for _, version := range found {
+		versions = append(versions, version.Version)
+	}
+	return FirstMatch(versions, filter)
+}
+
+// ListVersions returns the versions the plugin finds for a Docker Build arg, with the
+// params passed along. Semantic versions are sorted from oldest to newest, and otherwise
+// the order the plugin gave (newest first) is reversed.
+func (p *Plugin) ListVersions(name string, params map[string]string) ([]string, error) {
+	found, err := p.Versions(PluginRequest{Name: name, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	versions := []string{}
+	for i := len(found) - 1; i >= 0; i-- {
+		versions = append(versions, found[i].Version)
+	}
+
+	// Like GetVersions, only sort if it doesn't remove everything
+	if HasSemanticVersions(versions) {
+		if sorted := SortVersions(versions); len(sorted) > 0 {
+			versions = sorted
+		}
+	}
+	return versions, nil
+}
+
+// Created returns the creation times the plugin gave, if any
+func (p *Plugin) Created(name string, params map[string]string, versions []string) (map[string]time.Time, error) {
+	found, err := p.Versions(PluginRequest{Name: name, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	created := map[string]time.Time{}
+	for _, version := range found {
+		if !version.Created.IsZero() {
+			created[version.Version] = version.Created
+		}
+	}
+	return created, nil
+}
+
+// Versions runs the plugin and returns the versions it finds
+func (p *Plugin) Versions(request PluginRequest) ([]PluginVersion, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	if found, ok := p.found[string(input)]; ok {
+		return found, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), PluginTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s did not finish within %s", p.Path, PluginTimeout)
+		}
+		return nil, fmt.Errorf("%s failed: %s %s", p.Path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	response := PluginResponse{}
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("%s did not return valid json: %s", p.Path, err)
+	}
+	versions := []PluginVersion{}
+	for _, version := range response.Versions {
+		if version.Version != "" {
+			versions = append(versions, version)
+		}
+	}
+	if p.found == nil {
+		p.found = map[string][]PluginVersion{}
+	}
+	p.found[string(input)] = versions
+	return versions, nil
+}
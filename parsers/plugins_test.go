@@ -0,0 +1,170 @@
+package parsers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePlugin writes an executable shell script plugin for a source
+func writePlugin(t *testing.T, dir string, source string, script string) *Plugin {
+	path := filepath.Join(dir, PluginPrefix+source)
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return &Plugin{Source: source, Path: path}
+}
+
+func TestPluginVersions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The request is written next to the plugin, to check what it was given
+	request := filepath.Join(dir, "request.json")
+	found := writePlugin(t, dir, "found", `cat > `+request+`
+echo '{"versions": [{"version": "3.2.0", "created": "2024-05-01T00:00:00Z"}, {"version": ""}, {"version": "3.1.0"}]}'`)
+
+	tests := []struct {
+		name    string
+		plugin  *Plugin
+		filter  string
+		latest  string
+		failure string
+	}{
+		{"newest", found, "", "3.2.0", ""},
+		{"filtered", found, `^3\.1`, "3.1.0", ""},
+		{"no match", found, `^4`, "", "no values match"},
+		{"exits non-zero", writePlugin(t, dir, "broken", `echo "no such package" >&2; exit 1`), "", "", "no such package"},
+		{"not json", writePlugin(t, dir, "text", `echo 3.2.0`), "", "", "did not return valid json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latest, err := tt.plugin.Latest("uptodate_found_tool", "3.0.0", []string{"tools/tool"}, tt.filter)
+			if tt.failure != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.failure) {
+					t.Errorf("Latest() returned %v, want an error with %q", err, tt.failure)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Latest() returned an error: %s", err)
+			}
+			if latest != tt.latest {
+				t.Errorf("Latest() = %q, want %q", latest, tt.latest)
+			}
+		})
+	}
+
+	content, err := ioutil.ReadFile(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"uptodate_found_tool","value":"3.0.0","args":["tools/tool"]}`
+	if strings.TrimSpace(string(content)) != want {
+		t.Errorf("plugin was given %s, want %s", content, want)
+	}
+
+	// A build arg lists versions from oldest to newest, with the creation times given
+	versions, err := found.ListVersions("tool", map[string]string{"repo": "raw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(versions, []string{"3.1.0", "3.2.0"}) {
+		t.Errorf("ListVersions() = %v, want [3.1.0 3.2.0]", versions)
+	}
+	created, err := found.Created("tool", map[string]string{"repo": "raw"}, versions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || !created["3.2.0"].Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Created() = %v, want only 3.2.0 at 2024-05-01", created)
+	}
+}
+
+func TestPluginTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	timeout := PluginTimeout
+	PluginTimeout = 100 * time.Millisecond
+	defer func() { PluginTimeout = timeout }()
+
+	plugin := writePlugin(t, dir, "slow", `exec sleep 10`)
+	start := time.Now()
+	_, err = plugin.Versions(PluginRequest{Name: "tool"})
+	if err == nil || !strings.Contains(err.Error(), "did not finish") {
+		t.Errorf("Versions() returned %v, want a timeout error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Versions() took %s, the plugin was not stopped", elapsed)
+	}
+}
+
+func TestFindPlugin(t *testing.T) {
+	for _, source := range []string{"", "../found", "a/b"} {
+		if _, ok := FindPlugin(source); ok {
+			t.Errorf("FindPlugin(%q) found a plugin", source)
+		}
+	}
+}
+
+func TestPluginListVersionsOrder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Each run is counted, to check that a request only runs the plugin once
+	runs := filepath.Join(dir, "runs")
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"semantic", `{"versions": [{"version": "1.9.0"}, {"version": "1.10.0"}, {"version": "1.2.0"}]}`, []string{"1.2.0", "1.9.0", "1.10.0"}},
+		{"not semantic", `{"versions": [{"version": "nightly-3"}, {"version": "nightly-10"}, {"version": "nightly-1"}]}`, []string{"nightly-1", "nightly-10", "nightly-3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := writePlugin(t, dir, strings.Replace(tt.name, " ", "-", -1), `echo run >> `+runs+`
+echo '`+tt.output+`'`)
+			for i := 0; i < 2; i++ {
+				versions, err := plugin.ListVersions("tool", map[string]string{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(versions, tt.want) {
+					t.Errorf("ListVersions() = %v, want %v", versions, tt.want)
+				}
+			}
+		})
+	}
+	content, err := ioutil.ReadFile(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := strings.Count(string(content), "run"); count != len(tests) {
+		t.Errorf("plugins ran %d times, want %d", count, len(tests))
+	}
+}
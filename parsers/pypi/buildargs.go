@@ -0,0 +1,70 @@
+package pypi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vsoch/uptodate/parsers"
+)
+
+// Updater updates a pypi version build arg
+// ARG uptodate_pypi_<package>=<version>
+// # uptodate: pypi <package>
+type Updater struct{}
+
+func init() {
+	parsers.RegisterUpdater(&Updater{})
+}
+
+// Name of the updater, for directives
+func (u *Updater) Name() string {
+	return "pypi"
+}
+
+// Match a build arg named with the pypi prefix
+func (u *Updater) Match(name string) bool {
+	return strings.HasPrefix(name, "uptodate_pypi")
+}
+
+// Latest returns the newest release of the package (matching a filter, if given).
+// Pre-releases are only considered if the current value is one.
+func (u *Updater) Latest(name string, value string, args []string, filter string) (string, error) {
+	pkgName := strings.Replace(name, "uptodate_pypi_", "", 1)
+	if len(args) > 0 {
+		if len(args) != 1 {
+			return "", fmt.Errorf("the pypi source needs a package name")
+		}
+		pkgName = args[0]
+	} else {
+		fmt.Printf("Found pypi build arg prefix %s\n", name)
+	}
+
+	pkg, err := GetPackage(pkgName, "")
+	if err != nil {
+		return "", err
+	}
+	current, ok := ParseVersion(value)
+	versions := pkg.GetVersions(ok && current.IsPreRelease())
+	return parsers.NewestMatch(versions, filter)
+}
+
+// ListVersions returns released versions of a package for a Docker Build arg. The params
+// can set an index (the url of the JSON API) and prerelease (true to include them).
+func (u *Updater) ListVersions(name string, params map[string]string) ([]string, error) {
+	pkg, err := GetPackage(name, params["index"])
+	if err != nil {
+		return nil, err
+	}
+	return pkg.GetVersions(params["prerelease"] == "true"), nil
+}
+
+// Created returns when each version was first uploaded
+func (u *Updater) Created(name string, params map[string]string, versions []string) (map[string]time.Time, error) {
+	pkg, err := GetPackage(name, params["index"])
+	if err != nil {
+		return nil, err
+	}
+	_, uploaded := pkg.Released()
+	return uploaded, nil
+}
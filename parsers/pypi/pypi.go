@@ -0,0 +1,95 @@
+package pypi
+
+// The pypi parser reads package metadata from the PyPI JSON API, e.g.,
+// https://pypi.org/pypi/<package>/json
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+// IndexURL serves the JSON API, and can be set to a mirror (or a local stand-in)
+var IndexURL = "https://pypi.org/pypi"
+
+// CacheTTL is how long package metadata is cached
+var CacheTTL = time.Hour
+
+// A Package matches the format of <index>/<package>/json
+type Package struct {
+	Info     PackageInfo              `json:"info"`
+	Releases map[string][]ReleaseFile `json:"releases"`
+}
+
+// PackageInfo describes the package, and the latest version
+type PackageInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// A ReleaseFile is a wheel or sdist uploaded for a release
+type ReleaseFile struct {
+	Filename   string    `json:"filename"`
+	UploadTime time.Time `json:"upload_time_iso_8601"`
+	Yanked     bool      `json:"yanked"`
+}
+
+// nameSeparators are collapsed in a normalized name
+var nameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizeName normalizes a package name (PEP 503), e.g., Jupyter_Server is jupyter-server
+func NormalizeName(name string) string {
+	return strings.ToLower(nameSeparators.ReplaceAllString(name, "-"))
+}
+
+// GetPackage gets the metadata for a package from an index (the default if empty)
+func GetPackage(name string, index string) (Package, error) {
+	pkg := Package{}
+	if index == "" {
+		index = IndexURL
+	}
+	url := strings.TrimRight(index, "/") + "/" + NormalizeName(name) + "/json"
+	response, err := utils.GetCachedResponse(url, map[string]string{"Accept": "application/json"}, CacheTTL)
+	if err != nil {
+		return pkg, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return pkg, fmt.Errorf("%s returned status %d", url, response.StatusCode)
+	}
+	if err := json.Unmarshal(response.Body, &pkg); err != nil {
+		return pkg, fmt.Errorf("issue unmarshalling %s: %s", url, err)
+	}
+	return pkg, nil
+}
+
+// Released returns the versions that have files that are not yanked, and when
+// each was first uploaded
+func (p *Package) Released() ([]string, map[string]time.Time) {
+	versions := []string{}
+	uploaded := map[string]time.Time{}
+	for version, files := range p.Releases {
+		for _, file := range files {
+			if file.Yanked {
+				continue
+			}
+			if _, ok := uploaded[version]; !ok {
+				versions = append(versions, version)
+			}
+			if first, ok := uploaded[version]; !ok || file.UploadTime.Before(first) {
+				uploaded[version] = file.UploadTime
+			}
+		}
+	}
+	return versions, uploaded
+}
+
+// GetVersions returns released versions from oldest to newest, with pre-releases if wanted
+func (p *Package) GetVersions(preReleases bool) []string {
+	versions, _ := p.Released()
+	return SortVersions(versions, preReleases)
+}
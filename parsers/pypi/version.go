@@ -0,0 +1,190 @@
+package pypi
+
+// Versions are compared as described in PEP 440, e.g.,
+// 1.0.dev1 < 1.0a1 < 1.0b2.post1 < 1.0rc1 < 1.0 < 1.0+local < 1.0.post1
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// versionRegex is the permissive form of a version from PEP 440 (Appendix B)
+var versionRegex = regexp.MustCompile(`^v?(?:(?P<epoch>[0-9]+)!)?(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?P<pre>[-_.]?(?P<pre_l>a|b|c|rc|alpha|beta|pre|preview)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?P<post>(?:-(?P<post_n1>[0-9]+))|(?:[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?))?` +
+	`(?P<dev>[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// preReleases orders the pre-release phases, with their alternate spellings
+var preReleases = map[string]int{"a": 0, "alpha": 0, "b": 1, "beta": 1, "c": 2, "rc": 2, "pre": 2, "preview": 2}
+
+// Version is a parsed PEP 440 version
+type Version struct {
+	Original string
+	Epoch    int
+	Release  []int
+
+	// A missing pre, post, or dev release is -1
+	PrePhase int
+	Pre      int
+	Post     int
+	Dev      int
+	Local    []string
+}
+
+// ParseVersion parses a version, ok is false if it isn't a PEP 440 version
+func ParseVersion(version string) (Version, bool) {
+	match := versionRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(version)))
+	if match == nil {
+		return Version{}, false
+	}
+	groups := map[string]string{}
+	for i, name := range versionRegex.SubexpNames() {
+		if name != "" {
+			groups[name] = match[i]
+		}
+	}
+
+	v := Version{Original: version, PrePhase: -1, Pre: -1, Post: -1, Dev: -1}
+	v.Epoch = number(groups["epoch"])
+	for _, part := range strings.Split(groups["release"], ".") {
+		v.Release = append(v.Release, number(part))
+	}
+	if groups["pre"] != "" {
+		v.PrePhase = preReleases[groups["pre_l"]]
+		v.Pre = number(groups["pre_n"])
+	}
+	if groups["post"] != "" {
+		v.Post = number(groups["post_n1"] + groups["post_n2"])
+	}
+	if groups["dev"] != "" {
+		v.Dev = number(groups["dev_n"])
+	}
+	if groups["local"] != "" {
+		v.Local = strings.FieldsFunc(groups["local"], func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+	return v, true
+}
+
+// number parses a number that the regular expression already matched, empty is 0
+func number(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
+// IsPreRelease is true for alpha, beta, release candidate, and development releases
+func (v *Version) IsPreRelease() bool {
+	return v.PrePhase >= 0 || v.Dev >= 0
+}
+
+// Compare returns -1, 0, or 1 if the version is less than, equal to, or greater than another
+func (v *Version) Compare(other Version) int {
+	if c := compareInts([]int{v.Epoch}, []int{other.Epoch}); c != 0 {
+		return c
+	}
+	if c := compareInts(trimZeros(v.Release), trimZeros(other.Release)); c != 0 {
+		return c
+	}
+	if c := compareInts(v.key(), other.key()); c != 0 {
+		return c
+	}
+	return compareLocal(v.Local, other.Local)
+}
+
+// key orders the pre, post, and dev releases of the same release
+func (v *Version) key() []int {
+
+	// A development release of a final release (1.0.dev1) comes before its pre-releases
+	phase, pre := v.PrePhase, v.Pre
+	if phase < 0 {
+		phase = 3
+		if v.Post < 0 && v.Dev >= 0 {
+			phase = -1
+		}
+	}
+
+	// Without a development release, a release comes after its development releases
+	dev := v.Dev
+	if dev < 0 {
+		dev = int(^uint(0) >> 1)
+	}
+	return []int{phase, pre, v.Post, dev}
+}
+
+// trimZeros drops trailing zeros from a release, so 1.0 and 1.0.0 are equal
+func trimZeros(release []int) []int {
+	end := len(release)
+	for end > 1 && release[end-1] == 0 {
+		end--
+	}
+	return release[:end]
+}
+
+// compareInts compares two lists of numbers in order, a shorter list is less
+func compareInts(one []int, two []int) int {
+	for i := 0; i < len(one) && i < len(two); i++ {
+		if one[i] < two[i] {
+			return -1
+		}
+		if one[i] > two[i] {
+			return 1
+		}
+	}
+	if len(one) < len(two) {
+		return -1
+	}
+	if len(one) > len(two) {
+		return 1
+	}
+	return 0
+}
+
+// compareLocal compares local versions, where numbers are greater than words
+func compareLocal(one []string, two []string) int {
+	for i := 0; i < len(one) && i < len(two); i++ {
+		n1, err1 := strconv.Atoi(one[i])
+		n2, err2 := strconv.Atoi(two[i])
+		switch {
+		case err1 == nil && err2 == nil:
+			if c := compareInts([]int{n1}, []int{n2}); c != 0 {
+				return c
+			}
+		case err1 == nil:
+			return 1
+		case err2 == nil:
+			return -1
+		case one[i] != two[i]:
+			if one[i] < two[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return compareInts(make([]int, len(one)), make([]int, len(two)))
+}
+
+// SortVersions sorts versions from oldest to newest, dropping any that
+// aren't PEP 440 versions (and pre-releases unless they are wanted)
+func SortVersions(contenders []string, preReleases bool) []string {
+	versions := []Version{}
+	for _, contender := range contenders {
+		version, ok := ParseVersion(contender)
+		if !ok || (version.IsPreRelease() && !preReleases) {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+
+	sorted := []string{}
+	for _, version := range versions {
+		sorted = append(sorted, version.Original)
+	}
+	return sorted
+}
@@ -0,0 +1,155 @@
+package pypi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/vsoch/uptodate/utils"
+)
+
+// ordered are versions from oldest to newest, from the examples in PEP 440
+var ordered = []string{
+	"1.0.dev456",
+	"1.0a1",
+	"1.0a2.dev456",
+	"1.0a12.dev456",
+	"1.0a12",
+	"1.0b1.dev456",
+	"1.0b2",
+	"1.0b2.post345.dev456",
+	"1.0b2.post345",
+	"1.0rc1.dev456",
+	"1.0rc1",
+	"1.0",
+	"1.0+abc.5",
+	"1.0+abc.7",
+	"1.0+5",
+	"1.0.post456.dev34",
+	"1.0.post456",
+	"1.0.15",
+	"1.1.dev1",
+	"1!0.1",
+}
+
+func TestSortVersions(t *testing.T) {
+	reversed := []string{}
+	for i := len(ordered) - 1; i >= 0; i-- {
+		reversed = append(reversed, ordered[i])
+	}
+	if got := SortVersions(reversed, true); !reflect.DeepEqual(got, ordered) {
+		t.Errorf("SortVersions() = %v, want %v", got, ordered)
+	}
+
+	// Pre-releases and anything that isn't a version are dropped
+	contenders := []string{"2.0rc1", "1.10", "not-a-version", "1.9", "1.5.dev1", "1.9.post1"}
+	want := []string{"1.9", "1.9.post1", "1.10"}
+	if got := SortVersions(contenders, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("SortVersions(%v, false) = %v, want %v", contenders, got, want)
+	}
+}
+
+func TestCompareEqual(t *testing.T) {
+	tests := [][2]string{
+		{"1.0", "1.0.0"},
+		{"v1.0", "1.0"},
+		{"1.0a1", "1.0alpha1"},
+		{"1.0a1", "1.0-a.1"},
+		{"1.0b1", "1.0beta1"},
+		{"1.0rc1", "1.0c1"},
+		{"1.0rc1", "1.0preview1"},
+		{"1.0.post1", "1.0-1"},
+		{"1.0.post1", "1.0.rev1"},
+		{"1.0a", "1.0a0"},
+		{"1.0.dev", "1.0.dev0"},
+		{"1.0+ABC", "1.0+abc"},
+	}
+	for _, tt := range tests {
+		one, ok1 := ParseVersion(tt[0])
+		two, ok2 := ParseVersion(tt[1])
+		if !ok1 || !ok2 {
+			t.Errorf("ParseVersion(%q) or ParseVersion(%q) failed", tt[0], tt[1])
+			continue
+		}
+		if c := one.Compare(two); c != 0 {
+			t.Errorf("%s compared to %s = %d, want 0", tt[0], tt[1], c)
+		}
+	}
+}
+
+func TestIsPreRelease(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"1.0", false},
+		{"1.0.post1", false},
+		{"1.0+local", false},
+		{"1.0a1", true},
+		{"1.0rc2", true},
+		{"1.0.dev3", true},
+		{"1.0.post1.dev1", true},
+	}
+	for _, tt := range tests {
+		v, ok := ParseVersion(tt.version)
+		if !ok {
+			t.Errorf("ParseVersion(%q) failed", tt.version)
+			continue
+		}
+		if got := v.IsPreRelease(); got != tt.want {
+			t.Errorf("IsPreRelease() for %s = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestListVersions(t *testing.T) {
+	utils.CacheDisabled = true
+	defer func() { utils.CacheDisabled = false }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jupyter-server/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"info": {"name": "jupyter_server", "version": "2.0.0"}, "releases": {
+			"1.10.0": [{"filename": "a.whl", "upload_time_iso_8601": "2021-08-01T00:00:00Z"}],
+			"1.9.0": [{"filename": "b.whl", "upload_time_iso_8601": "2021-07-01T00:00:00Z"},
+			          {"filename": "b.tar.gz", "upload_time_iso_8601": "2021-06-30T00:00:00Z"}],
+			"2.0.0rc1": [{"filename": "c.whl", "upload_time_iso_8601": "2021-09-01T00:00:00Z"}],
+			"2.0.0": [{"filename": "d.whl", "upload_time_iso_8601": "2021-10-01T00:00:00Z", "yanked": true}],
+			"0.1.0": []}}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		params map[string]string
+		want   []string
+	}{
+		{map[string]string{"index": server.URL}, []string{"1.9.0", "1.10.0"}},
+		{map[string]string{"index": server.URL, "prerelease": "true"}, []string{"1.9.0", "1.10.0", "2.0.0rc1"}},
+	}
+	updater := Updater{}
+	for _, tt := range tests {
+		got, err := updater.ListVersions("Jupyter_Server", tt.params)
+		if err != nil {
+			t.Fatalf("ListVersions() returned an error: %s", err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ListVersions(%v) = %v, want %v", tt.params, got, tt.want)
+		}
+	}
+
+	// A release was created when its first file was uploaded
+	created, err := updater.Created("jupyter-server", map[string]string{"index": server.URL}, []string{"1.9.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := created["1.9.0"].Format("2006-01-02"); got != "2021-06-30" {
+		t.Errorf("1.9.0 was created %s, want 2021-06-30", got)
+	}
+
+	if _, err := updater.ListVersions("missing", map[string]string{"index": server.URL}); err == nil {
+		t.Errorf("ListVersions() for a missing package did not return an error")
+	}
+}
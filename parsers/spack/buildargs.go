@@ -7,43 +7,42 @@ import (
 	"github.com/vsoch/uptodate/parsers"
 )
 
-// UpdateBuildArg will update a spack version build arg
-// Should be called from docker.go UpdateArg to ensure intiial checks
+// Updater updates a spack version build arg
 // ARG uptodate_spack_<package>=<version>
-func UpdateBuildArg(values []string) parsers.Update {
+// # uptodate: spack <package>
+type Updater struct{}
 
-	// This is the full argument with =
-	arg := values[0]
-	update := parsers.Update{}
-	fmt.Printf("Found spack build arg prefix %s\n", arg)
-
-	// Split into buildarg name and value
-	parts := strings.SplitN(arg, "=", 2)
-	name := parts[0]
-
-	// Keep the original for later comparison
-	original := strings.Join(values, " ")
-	name = strings.Replace(name, "uptodate_spack_", "", 1)
-
-	// Get versions for current spack package
-	pkg := GetSpackPackage(name)
+func init() {
+	parsers.RegisterUpdater(&Updater{})
+}
 
-	// Should be sorted with newest first
-	if len(pkg.Versions) > 0 {
+// Name of the updater, for directives
+func (u *Updater) Name() string {
+	return "spack"
+}
 
-		updated := parts[0] + "=" + pkg.Versions[0].Name
+// Match a build arg named with the spack prefix
+func (u *Updater) Match(name string) bool {
+	return strings.HasPrefix(name, "uptodate_spack")
+}
 
-		// Add any comments back
-		for _, extra := range values[1:] {
-			updated += " " + extra
+// Latest returns the newest version of the package (matching a filter, if given)
+func (u *Updater) Latest(name string, value string, args []string, filter string) (string, error) {
+	pkgName := strings.Replace(name, "uptodate_spack_", "", 1)
+	if len(args) > 0 {
+		if len(args) != 1 {
+			return "", fmt.Errorf("the spack source needs a package name")
 		}
+		pkgName = args[0]
+	} else {
+		fmt.Printf("Found spack build arg prefix %s\n", name)
+	}
 
-		// If the updated version is different from the original, update
-		if updated != original {
-			update = parsers.Update{Original: original, Updated: updated}
-		} else {
-			fmt.Println("No difference between:", updated, original)
-		}
+	// Get versions for current spack package, should be sorted with newest first
+	pkg := GetSpackPackage(pkgName)
+	versions := []string{}
+	for _, version := range pkg.Versions {
+		versions = append(versions, version.Name)
 	}
-	return update
+	return parsers.FirstMatch(versions, filter)
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"log"
 	"sort"
+	"time"
 
 	"github.com/vsoch/uptodate/parsers"
 	"github.com/vsoch/uptodate/utils"
@@ -65,12 +66,15 @@ type SpackDependency struct {
 	Description string `json:"description"`
 }
 
+// CacheTTL is how long package metadata is cached, the data is updated nightly
+var CacheTTL = 24 * time.Hour
+
 // getSpackPackage uses the github.com/spack/packages API to get package metadata
 func GetSpackPackage(name string) SpackPackage {
 
 	// Get versions for current spack package
 	packageUrl := "https://spack.github.io/packages.spack.io/data/packages/" + name + ".json"
-	response := utils.GetRequest(packageUrl, map[string]string{})
+	response := utils.GetCachedRequest(packageUrl, map[string]string{}, CacheTTL)
 
 	// The response gets parsed into a spack package
 	pkg := SpackPackage{}
@@ -0,0 +1,156 @@
+package parsers
+
+// Updaters find the latest value for a build arg from some source (e.g., spack).
+// They register by name, and the Dockerfile parser asks them in order of registration.
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Updater resolves the latest value of a build arg
+type Updater interface {
+
+	// Name of the source, used in directives, e.g., # uptodate: spack ace
+	Name() string
+
+	// Match determines if a build arg name is for this updater, e.g., uptodate_spack_ace
+	Match(name string) bool
+
+	// Latest returns the latest value for a build arg, given its name and current value.
+	// The arguments come from a directive, and without them the updater derives what
+	// to look up from the name. If a filter is given, the latest value must match it.
+	Latest(name string, value string, args []string, filter string) (string, error)
+}
+
+// VersionLister is an updater that can also list versions, to be a Docker Build arg type
+type VersionLister interface {
+
+	// ListVersions returns the versions for a name (e.g., a package) from oldest to newest.
+	// The params come from the build arg, e.g., an index to use.
+	ListVersions(name string, params map[string]string) ([]string, error)
+
+	// Created returns when versions were created, to filter by age. Versions with an
+	// unknown creation time can be left out.
+	Created(name string, params map[string]string, versions []string) (map[string]time.Time, error)
+}
+
+// updaters are registered updaters, in order
+var updaters = []Updater{}
+
+// RegisterUpdater adds an updater, replacing one with the same name
+func RegisterUpdater(updater Updater) {
+	for i, existing := range updaters {
+		if existing.Name() == updater.Name() {
+			updaters[i] = updater
+			return
+		}
+	}
+	updaters = append(updaters, updater)
+}
+
+// GetUpdater returns an updater by name, looking for a plugin on the PATH last
+func GetUpdater(name string) (Updater, bool) {
+	if updater, ok := registeredUpdater(name); ok {
+		return updater, true
+	}
+	if plugin, ok := FindPlugin(name); ok {
+		RegisterUpdater(plugin)
+		return plugin, true
+	}
+	return nil, false
+}
+
+// registeredUpdater returns an updater by name, without looking for plugins
+func registeredUpdater(name string) (Updater, bool) {
+	for _, updater := range updaters {
+		if updater.Name() == name {
+			return updater, true
+		}
+	}
+	return nil, false
+}
+
+// MatchUpdater returns the first updater that matches a build arg name
+func MatchUpdater(name string) (Updater, bool) {
+	discoverPlugins()
+	for _, updater := range updaters {
+		if updater.Match(name) {
+			return updater, true
+		}
+	}
+	return nil, false
+}
+
+// Updaters returns the names of registered updaters
+func Updaters() []string {
+	discoverPlugins()
+	names := []string{}
+	for _, updater := range updaters {
+		names = append(names, updater.Name())
+	}
+	return names
+}
+
+// UpdateBuildArg uses an updater to update the values of an ARG (name=value, and any extras)
+func UpdateBuildArg(values []string, updater Updater, args []string, filter string) Update {
+
+	// We will return an update, empty if none
+	update := Update{}
+
+	// Keep the original for later comparison
+	original := strings.Join(values, " ")
+	parts := strings.SplitN(values[0], "=", 2)
+
+	latest, err := updater.Latest(parts[0], parts[1], args, filter)
+	if err != nil {
+		fmt.Printf("Cannot update %s with %s: %s\n", parts[0], updater.Name(), err)
+		return update
+	}
+	updated := parts[0] + "=" + latest
+
+	// Add original content back
+	for _, extra := range values[1:] {
+		updated += " " + extra
+	}
+
+	// If the updated version is different from the original, update
+	if updated != original {
+		update = Update{Original: original, Updated: updated}
+	} else {
+		fmt.Println("No difference between:", updated, original)
+	}
+	return update
+}
+
+// NewestMatch returns the newest value (of values from oldest to newest) that matches a
+// filter, or the newest if there is no filter
+func NewestMatch(values []string, filter string) (string, error) {
+	newest := []string{}
+	for i := len(values) - 1; i >= 0; i-- {
+		newest = append(newest, values[i])
+	}
+	return FirstMatch(newest, filter)
+}
+
+// FirstMatch returns the first value that matches a filter, or the first if there is no filter
+func FirstMatch(values []string, filter string) (string, error) {
+	if filter == "" {
+		if len(values) == 0 {
+			return "", fmt.Errorf("no values found")
+		}
+		return values[0], nil
+	}
+	isMatch, err := regexp.Compile(filter)
+	if err != nil {
+		return "", fmt.Errorf("cannot use filter %s: %s", filter, err)
+	}
+	for _, value := range values {
+		if isMatch.MatchString(value) {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("no values match %s", filter)
+}
@@ -0,0 +1,142 @@
+package parsers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testUpdater answers with a fixed value, or an error if there is none
+type testUpdater struct {
+	name   string
+	prefix string
+	latest string
+}
+
+func (u testUpdater) Name() string { return u.name }
+
+func (u testUpdater) Match(name string) bool { return strings.HasPrefix(name, u.prefix) }
+
+func (u testUpdater) Latest(name string, value string, args []string, filter string) (string, error) {
+	if u.latest == "" {
+		return "", fmt.Errorf("no versions")
+	}
+	return u.latest + strings.Join(args, ""), nil
+}
+
+// withUpdaters runs a test with only the given updaters registered
+func withUpdaters(registered []Updater, test func()) {
+	saved := updaters
+	defer func() { updaters = saved }()
+	updaters = []Updater{}
+	for _, updater := range registered {
+		RegisterUpdater(updater)
+	}
+	test()
+}
+
+func TestRegisterUpdater(t *testing.T) {
+	first := testUpdater{name: "first", prefix: "uptodate_", latest: "1.0"}
+	second := testUpdater{name: "second", prefix: "uptodate_second", latest: "2.0"}
+	replaced := testUpdater{name: "first", prefix: "uptodate_first", latest: "3.0"}
+	withUpdaters([]Updater{first, second}, func() {
+
+		// The first registered updater that matches wins
+		if updater, ok := MatchUpdater("uptodate_second_app"); !ok || updater.Name() != "first" {
+			t.Errorf("MatchUpdater(uptodate_second_app) = %v, %v, want first", updater, ok)
+		}
+
+		// Registering the same name replaces it, in the same place
+		RegisterUpdater(replaced)
+		if names := Updaters(); !reflect.DeepEqual(names, []string{"first", "second"}) {
+			t.Errorf("Updaters() = %v, want [first second]", names)
+		}
+		if updater, ok := MatchUpdater("uptodate_second_app"); !ok || updater.Name() != "second" {
+			t.Errorf("MatchUpdater(uptodate_second_app) = %v, %v, want second", updater, ok)
+		}
+		if updater, ok := GetUpdater("first"); !ok || updater != Updater(replaced) {
+			t.Errorf("GetUpdater(first) = %v, %v, want the replacement", updater, ok)
+		}
+		if _, ok := MatchUpdater("other"); ok {
+			t.Errorf("MatchUpdater(other) found an updater")
+		}
+		if _, ok := GetUpdater("third"); ok {
+			t.Errorf("GetUpdater(third) found an updater")
+		}
+	})
+}
+
+func TestUpdateBuildArg(t *testing.T) {
+	updater := testUpdater{name: "test", latest: "2.0"}
+	tests := []struct {
+		values  []string
+		updater Updater
+		args    []string
+		want    Update
+	}{
+		{[]string{"uptodate_app=1.0"}, updater, []string{}, Update{Original: "uptodate_app=1.0", Updated: "uptodate_app=2.0"}},
+		{[]string{"uptodate_app=1.0", "other=1"}, updater, []string{"-slim"}, Update{Original: "uptodate_app=1.0 other=1", Updated: "uptodate_app=2.0-slim other=1"}},
+		{[]string{"uptodate_app=2.0"}, updater, []string{}, Update{}},
+		{[]string{"uptodate_app=1.0"}, testUpdater{name: "empty"}, []string{}, Update{}},
+	}
+	for _, tt := range tests {
+		if got := UpdateBuildArg(tt.values, tt.updater, tt.args, ""); got != tt.want {
+			t.Errorf("UpdateBuildArg(%v, %s) = %+v, want %+v", tt.values, tt.updater.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestFirstMatch(t *testing.T) {
+	values := []string{"2.0.0", "1.1.0", "1.0.0"}
+	tests := []struct {
+		values  []string
+		filter  string
+		want    string
+		wantErr bool
+	}{
+		{values, "", "2.0.0", false},
+		{values, `^1\.`, "1.1.0", false},
+		{values, `^3\.`, "", true},
+		{values, `(`, "", true},
+		{[]string{}, "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := FirstMatch(tt.values, tt.filter)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("FirstMatch(%v, %q) = %q, %v, want %q", tt.values, tt.filter, got, err, tt.want)
+		}
+	}
+}
+
+func TestNewestMatch(t *testing.T) {
+	versions := []string{"1.0.0", "1.1.0", "2.0.0-rc.1", "2.0.0"}
+	tests := []struct {
+		values  []string
+		filter  string
+		want    string
+		wantErr bool
+	}{
+		{versions, "", "2.0.0", false},
+		{versions, `^1\.`, "1.1.0", false},
+		{versions, `-rc`, "2.0.0-rc.1", false},
+		{versions, `^3\.`, "", true},
+		{versions, `(`, "", true},
+		{[]string{}, "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NewestMatch(tt.values, tt.filter)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewestMatch(%v, %q) returned error %v, want an error: %v", tt.values, tt.filter, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NewestMatch(%v, %q) = %q, want %q", tt.values, tt.filter, got, tt.want)
+		}
+	}
+
+	// The values are left in order
+	if versions[0] != "1.0.0" {
+		t.Errorf("NewestMatch changed the order of the values: %v", versions)
+	}
+}
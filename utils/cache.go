@@ -0,0 +1,142 @@
+package utils
+
+// A persistent cache for remote lookups, keyed by url
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache behavior, set from the command line
+var (
+	CacheDisabled = false // --no-cache: don't read or write the cache
+	CacheRefresh  = false // --refresh: revalidate every entry regardless of age
+)
+
+// Headers we keep alongside a cached body
+var cachedHeaders = []string{"Content-Type", "Docker-Content-Digest", "ETag", "Link"}
+
+// CacheEntry is a saved response for a url
+type CacheEntry struct {
+	Key     string            `json:"key"`
+	ETag    string            `json:"etag,omitempty"`
+	Fetched time.Time         `json:"fetched"`
+	Header  map[string]string `json:"header,omitempty"`
+	Body    []byte            `json:"body"`
+}
+
+// Response returns the entry as a response
+func (e *CacheEntry) Response() *Response {
+	header := http.Header{}
+	for key, value := range e.Header {
+		header.Set(key, value)
+	}
+	return &Response{StatusCode: http.StatusOK, Header: header, Body: e.Body}
+}
+
+// CacheDir is UPTODATE_CACHE_DIR, or uptodate under the user cache directory
+func CacheDir() string {
+	if dir := os.Getenv("UPTODATE_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "uptodate")
+}
+
+// cachePath is the file for a cache key
+func cachePath(key string) string {
+	return filepath.Join(CacheDir(), fmt.Sprintf("%x.json", sha256.Sum256([]byte(key))))
+}
+
+// ReadCache reads an entry, if it exists
+func ReadCache(key string) (CacheEntry, bool) {
+	entry := CacheEntry{}
+	content, err := ioutil.ReadFile(cachePath(key))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(content, &entry); err != nil || entry.Key != key {
+		return entry, false
+	}
+	return entry, true
+}
+
+// WriteCache saves an entry, a failure to write is not fatal
+func WriteCache(entry CacheEntry) {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if err := os.MkdirAll(CacheDir(), 0755); err != nil {
+		log.Println(err)
+		return
+	}
+
+	// Write to a temporary file first so a reader never sees a partial entry
+	path := cachePath(entry.Key)
+	tmp, err := ioutil.TempFile(CacheDir(), ".tmp-")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	_, err = tmp.Write(content)
+	tmp.Close()
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Println(err)
+	}
+}
+
+// CachedRequest returns a cached response for a key if it is younger than the ttl.
+// Otherwise the do function performs the request, with If-None-Match set in the
+// headers when we have an ETag, and a 304 (not modified) returns the cached response.
+func CachedRequest(key string, ttl time.Duration, do func(headers map[string]string) (*Response, error)) (*Response, error) {
+	headers := map[string]string{}
+	if CacheDisabled || fixturesEnabled {
+		return do(headers)
+	}
+
+	entry, found := ReadCache(key)
+	if found && !CacheRefresh && time.Since(entry.Fetched) < ttl {
+		return entry.Response(), nil
+	}
+	if found && entry.ETag != "" {
+		headers["If-None-Match"] = entry.ETag
+	}
+	response, err := do(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	// Not modified means the entry is good for another ttl
+	if response.StatusCode == http.StatusNotModified && found {
+		entry.Fetched = time.Now()
+		WriteCache(entry)
+		return entry.Response(), nil
+	}
+	if response.StatusCode == http.StatusOK {
+		entry = CacheEntry{Key: key, ETag: response.Header.Get("ETag"), Fetched: time.Now(),
+			Header: map[string]string{}, Body: response.Body}
+		for _, name := range cachedHeaders {
+			if value := response.Header.Get(name); value != "" {
+				entry.Header[name] = value
+			}
+		}
+		WriteCache(entry)
+	}
+	return response, nil
+}
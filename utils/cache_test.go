@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCachedRequest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("UPTODATE_CACHE_DIR", os.Getenv("UPTODATE_CACHE_DIR"))
+	os.Setenv("UPTODATE_CACHE_DIR", dir)
+
+	// The remote has an ETag, and answers not modified when it is sent back
+	requests := 0
+	body := "v1"
+	do := func(headers map[string]string) (*Response, error) {
+		requests++
+		if headers["If-None-Match"] == `"`+body+`"` {
+			return &Response{StatusCode: http.StatusNotModified, Header: http.Header{}}, nil
+		}
+		header := http.Header{}
+		header.Set("ETag", `"`+body+`"`)
+		return &Response{StatusCode: http.StatusOK, Header: header, Body: []byte(body)}, nil
+	}
+	get := func(ttl time.Duration) string {
+		response, err := CachedRequest("https://example.com/tags", ttl, do)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(response.Body)
+	}
+
+	// A fresh entry is returned without a request
+	if got := get(time.Hour); got != "v1" || requests != 1 {
+		t.Errorf("first request = %q after %d requests, want v1 after 1", got, requests)
+	}
+	if got := get(time.Hour); got != "v1" || requests != 1 {
+		t.Errorf("fresh entry = %q after %d requests, want v1 after 1", got, requests)
+	}
+
+	// An expired entry is revalidated, and not modified keeps the cached body
+	if got := get(0); got != "v1" || requests != 2 {
+		t.Errorf("revalidated entry = %q after %d requests, want v1 after 2", got, requests)
+	}
+	entry, found := ReadCache("https://example.com/tags")
+	if !found || time.Since(entry.Fetched) > time.Minute {
+		t.Errorf("a not modified response did not renew the entry: %+v", entry)
+	}
+
+	// A changed remote replaces the entry
+	body = "v2"
+	if got := get(0); got != "v2" || requests != 3 {
+		t.Errorf("changed entry = %q after %d requests, want v2 after 3", got, requests)
+	}
+	if got := get(time.Hour); got != "v2" || requests != 3 {
+		t.Errorf("replaced entry = %q after %d requests, want v2 after 3", got, requests)
+	}
+
+	// Refreshing revalidates a fresh entry, and disabling skips the cache
+	CacheRefresh = true
+	get(time.Hour)
+	CacheRefresh = false
+	if requests != 4 {
+		t.Errorf("--refresh made %d requests, want 4", requests)
+	}
+	CacheDisabled = true
+	body = "v3"
+	got := get(time.Hour)
+	CacheDisabled = false
+	if got != "v3" || requests != 5 {
+		t.Errorf("--no-cache = %q after %d requests, want v3 after 5", got, requests)
+	}
+	if entry, _ := ReadCache("https://example.com/tags"); string(entry.Body) != "v2" {
+		t.Errorf("--no-cache wrote the cache, entry is %q", entry.Body)
+	}
+}
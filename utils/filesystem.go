@@ -134,7 +134,7 @@ func RecursiveFind(root string, pattern string, allowPrefix bool) ([]string, err
 	})
 
 	if err != nil {
-		log.Fatal("Error running RecursiveFind to find files %s", err)
+		log.Fatalf("Error running RecursiveFind to find files %s", err)
 	}
 	return results, err
 }
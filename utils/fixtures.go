@@ -0,0 +1,185 @@
+package utils
+
+// Record and replay every http exchange, for offline runs and tests.
+// UPTODATE_FIXTURES is the fixtures directory, and UPTODATE_FIXTURES_MODE
+// is record or replay (the default).
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Fixture modes
+const (
+	FixturesRecord = "record"
+	FixturesReplay = "replay"
+)
+
+// Fixture is a saved http exchange
+type Fixture struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Accept     string            `json:"accept,omitempty"`
+	StatusCode int               `json:"status"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       string            `json:"body"`
+}
+
+// fixtureTransport records exchanges from the next transport, or replays them
+type fixtureTransport struct {
+	Dir  string
+	Mode string
+	next http.RoundTripper
+}
+
+// fixturesEnabled is true when recording or replaying, which bypasses the cache
+var fixturesEnabled = false
+
+func init() {
+	dir := os.Getenv("UPTODATE_FIXTURES")
+	if dir == "" {
+		return
+	}
+	if err := UseFixtures(dir, os.Getenv("UPTODATE_FIXTURES_MODE")); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// UseFixtures records or replays all requests of the HttpClient in a directory
+func UseFixtures(dir string, mode string) error {
+	if mode == "" {
+		mode = FixturesReplay
+	}
+	if mode != FixturesRecord && mode != FixturesReplay {
+		return fmt.Errorf("%s is not a fixtures mode, choices are %s or %s", mode, FixturesRecord, FixturesReplay)
+	}
+	if mode == FixturesRecord {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	next := HttpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	HttpClient = &http.Client{Transport: &fixtureTransport{Dir: dir, Mode: mode, next: next}}
+	fixturesEnabled = true
+	return nil
+}
+
+// fixturePath names a fixture by the method, url, and accepted types of a request
+func (t *fixtureTransport) fixturePath(req *http.Request) string {
+	key := req.Method + " " + req.URL.String() + " " + req.Header.Get("Accept")
+	return filepath.Join(t.Dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(key))))
+}
+
+// RoundTrip replays a saved response, or performs and saves the request
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := t.fixturePath(req)
+	if t.Mode == FixturesReplay {
+		return t.replay(req, path)
+	}
+
+	response, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := Fixture{Method: req.Method, URL: req.URL.String(), Accept: req.Header.Get("Accept"),
+		StatusCode: response.StatusCode, Header: map[string]string{}, Body: string(body)}
+	for name := range response.Header {
+		fixture.Header[name] = response.Header.Get(name)
+	}
+	content, err := json.MarshalIndent(fixture.redacted(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		return nil, err
+	}
+	return fixture.response(req), nil
+}
+
+// Redacted replaces secrets in fixtures, which are meant to be committed
+var Redacted = "REDACTED"
+
+// redactedFields are secrets in a json body, e.g., from a registry token endpoint
+var redactedFields = []string{"token", "access_token", "refresh_token"}
+
+// redactedHeaders are secrets in response headers
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Set-Cookie"}
+
+// redacted returns a copy of the fixture without tokens or auth headers
+func (f Fixture) redacted() Fixture {
+	header := map[string]string{}
+	for name, value := range f.Header {
+		if IncludesString(http.CanonicalHeaderKey(name), redactedHeaders) {
+			value = Redacted
+		}
+		header[name] = value
+	}
+	f.Header = header
+
+	body := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(f.Body), &body); err != nil {
+		return f
+	}
+	changed := false
+	for _, field := range redactedFields {
+		if _, ok := body[field]; ok {
+			body[field] = Redacted
+			changed = true
+		}
+	}
+	if changed {
+		if content, err := json.Marshal(body); err == nil {
+			f.Body = string(content)
+		}
+	}
+	return f
+}
+
+// replay loads a fixture, a missing one is fatal so tests can't pass by accident
+func (t *fixtureTransport) replay(req *http.Request, path string) (*http.Response, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("No fixture for %s %s (Accept: %q) in %s, record it with UPTODATE_FIXTURES_MODE=record\n",
+			req.Method, req.URL.String(), req.Header.Get("Accept"), t.Dir)
+	}
+	fixture := Fixture{}
+	if err := json.Unmarshal(content, &fixture); err != nil {
+		log.Fatalf("Fixture %s is not valid: %s\n", path, err)
+	}
+	return fixture.response(req), nil
+}
+
+// response turns the fixture back into an http response
+func (f *Fixture) response(req *http.Request) *http.Response {
+	header := http.Header{}
+	for name, value := range f.Header {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(f.Body))),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}
+}
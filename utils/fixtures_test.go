@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixtureRedacted(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		body   string
+		want   Fixture
+	}{
+		{
+			"token response",
+			map[string]string{"Content-Type": "application/json", "Set-Cookie": "session=secret"},
+			`{"token": "secret", "access_token": "secret", "expires_in": 300}`,
+			Fixture{Header: map[string]string{"Content-Type": "application/json", "Set-Cookie": Redacted},
+				Body: `{"access_token":"REDACTED","expires_in":300,"token":"REDACTED"}`},
+		},
+		{
+			"lowercase header",
+			map[string]string{"authorization": "Bearer secret"},
+			`{"tags": ["1.0"]}`,
+			Fixture{Header: map[string]string{"authorization": Redacted}, Body: `{"tags": ["1.0"]}`},
+		},
+		{
+			"not json",
+			map[string]string{},
+			"token: secret",
+			Fixture{Header: map[string]string{}, Body: "token: secret"},
+		},
+	}
+	for _, tt := range tests {
+		fixture := Fixture{Header: tt.header, Body: tt.body}
+		got := fixture.redacted()
+		if got.Body != tt.want.Body || len(got.Header) != len(tt.want.Header) {
+			t.Errorf("%s: redacted() = %+v, want %+v", tt.name, got, tt.want)
+			continue
+		}
+		for name, value := range tt.want.Header {
+			if got.Header[name] != value {
+				t.Errorf("%s: redacted header %s = %q, want %q", tt.name, name, got.Header[name], value)
+			}
+		}
+
+		// The original is left alone, it is still returned to the caller
+		if fixture.Body != tt.body {
+			t.Errorf("%s: redacted() changed the original body", tt.name)
+		}
+	}
+}
+
+func TestRecordRedactsTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token": "secret"}`))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "uptodate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	transport := &fixtureTransport{Dir: dir, Mode: FixturesRecord, next: http.DefaultTransport}
+	client := &http.Client{Transport: transport}
+	response, err := client.Get(server.URL + "/token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if !strings.Contains(string(body), "secret") {
+		t.Errorf("recording returned %s, want the real token", body)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("recorded %d fixtures, want 1", len(files))
+	}
+	content, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "secret") {
+		t.Errorf("recorded fixture has the token: %s", content)
+	}
+
+	// Replay gives back the redacted fixture
+	transport.Mode = FixturesReplay
+	response, err = client.Get(server.URL + "/token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := map[string]string{}
+	json.NewDecoder(response.Body).Decode(&replayed)
+	response.Body.Close()
+	if replayed["token"] != Redacted {
+		t.Errorf("replayed token = %q, want %q", replayed["token"], Redacted)
+	}
+}
@@ -4,29 +4,77 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"time"
 )
 
-func GetRequest(url string, headers map[string]string) string {
+// HttpClient is shared by every remote lookup
+var HttpClient = http.DefaultClient
 
-	req, err := http.NewRequest("GET", url, nil)
+// Response is a read response, the body is already closed
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Do performs a request and reads the response
+func Do(req *http.Request) (*Response, error) {
+	response, err := HttpClient.Do(req)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
 	}
+	return &Response{StatusCode: response.StatusCode, Header: response.Header, Body: body}, nil
+}
 
-	for key, value := range headers {
-		req.Header.Set(key, value)
+// GetRequest performs a GET and returns the body as a string
+func GetRequest(url string, headers map[string]string) string {
+	response, err := get(url, headers)
+	if err != nil {
+		log.Fatal(err)
 	}
+	return string(response.Body)
+}
 
-	response, err := http.DefaultClient.Do(req)
+// GetCachedRequest performs a GET through the cache, with entries valid for the ttl
+func GetCachedRequest(url string, headers map[string]string, ttl time.Duration) string {
+	response, err := GetCachedResponse(url, headers, ttl)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer response.Body.Close()
+	return string(response.Body)
+}
 
-	// Read the response from the body, and return as string
-	body, err := ioutil.ReadAll(response.Body)
+// GetCachedResponse performs a GET through the cache, and returns the response
+// so the caller can check the status code
+func GetCachedResponse(url string, headers map[string]string, ttl time.Duration) (*Response, error) {
+
+	// The key includes the Accept header, which changes the response
+	key := url
+	if accept, ok := headers["Accept"]; ok {
+		key += "|Accept=" + accept
+	}
+	return CachedRequest(key, ttl, func(extra map[string]string) (*Response, error) {
+		for name, value := range headers {
+			extra[name] = value
+		}
+		return get(url, extra)
+	})
+}
+
+// get performs a GET with headers
+func get(url string, headers map[string]string) (*Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		log.Fatalln(err)
+		return nil, err
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
 	}
-	return string(body)
+	return Do(req)
 }
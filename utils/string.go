@@ -1,5 +1,9 @@
 package utils
 
+import (
+	"sort"
+)
+
 func GetLogo() string {
 	return `              _            _       _       
   _   _ _ __ | |_ ___   __| | __ _| |_ ___ 
@@ -48,3 +52,13 @@ func FindMissingInSecond(one []string, two []string) []string {
 	}
 	return difference
 }
+
+// SortedKeys returns the keys of a map in order, for output that doesn't change between runs
+func SortedKeys(mapping map[string]string) []string {
+	keys := make([]string, 0, len(mapping))
+	for key := range mapping {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}